@@ -3,12 +3,14 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-
-	"github.com/jondot/goweight/pkg"
-
+	"log"
+	"net/http"
+	"os"
 	"sort"
 	"strings"
 
+	"github.com/jondot/goweight/pkg"
+
 	kingpin "github.com/alecthomas/kingpin/v2"
 	
 	"github.com/dustin/go-humanize"
@@ -23,21 +25,131 @@ var (
 var (
 	jsonOutput = kingpin.Flag("json", "Output json").Short('j').Bool()
 	buildTags  = kingpin.Flag("tags", "Build tags").String()
-	packages   = kingpin.Arg("packages", "Packages to build").String()
+	buildMode  = kingpin.Flag("buildmode", "Build mode passed to go build (exe, pie, c-shared, c-archive, plugin, archive)").Enum(pkg.BuildModes...)
 	binaryFile = kingpin.Flag("binary", "Analyze a binary file instead of building").Short('b').String()
-	verbose    = kingpin.Flag("verbose", "Detailed output showing all packages").Short('v').Bool()
+	debugFile  = kingpin.Flag("debug-file", "Separate debug file (objcopy --only-keep-debug) with the symbols and DWARF of a stripped ELF binary").ExistingFile()
+	debugDirs  = kingpin.Flag("debug-dir", "Directory to search for the debug file by GNU build ID or .gnu_debuglink; repeatable").ExistingDirs()
+	memory     = kingpin.Flag("memory", "Show static memory (.data, .noptrdata, .bss, .noptrbss; ELF only) next to disk size, largest first").Bool()
+	view       = kingpin.Flag("view", "physical: code counts where it is; logical: inlined code counts against the inlined function's package (needs DWARF)").Default(pkg.ViewPhysical).Enum(pkg.Views...)
+	arch       = kingpin.Flag("arch", "Architecture slice to analyze in a Mach-O universal binary (e.g. arm64, amd64)").String()
+	verbose    = kingpin.Flag("verbose", "Show type metadata per row in text output and include symbols in JSON; use --group-by none to list every package").Short('v').Bool()
 	buildAnalysis = kingpin.Flag("build-analysis", "Analyze build process to show compilation sizes").Bool()
+	format     = kingpin.Flag("format", "Output format").Default("text").Enum("text", "json", "markdown", "csv", "tsv", "dot")
+	dotCluster = kingpin.Flag("dot-cluster", "Cluster packages by module in dot output").Bool()
+	dotMinSize = kingpin.Flag("dot-min-size", "Hide packages linking fewer bytes than this in dot output (e.g. 10KB)").Default("0").String()
+	groupBy    = kingpin.Flag("group-by", "Aggregate packages by module (default), depth=N, org or none").String()
+	only       = kingpin.Flag("only", "Only show modules of these origins (std, main, direct, indirect, dependency, cgo, unknown); repeatable").Enums(pkg.Origins...)
+	goModFile  = kingpin.Flag("go-mod", "go.mod of the main module, to tell direct from indirect dependencies of a binary given with -b").ExistingFile()
+	listSymbols   = kingpin.Flag("symbols", "List the largest individual symbols instead of packages").Bool()
+	top           = kingpin.Flag("top", "Number of symbols (with --symbols) or generic functions to list (0 for all)").Default("20").Int()
+	symbolPackage = kingpin.Flag("package", "List every symbol of one package").PlaceHolder("PATH").String()
+	rulesFile  = kingpin.Flag("rules", "JSON rules file mapping package patterns to named groups and budgets").String()
+
+	analyzeCmd = kingpin.Command("analyze", "Analyze the size of a build or binary").Default()
+	packages   = analyzeCmd.Arg("packages", "Packages to build").String()
+	tuiCmd     = kingpin.Command("tui", "Browse the analysis in an interactive terminal UI")
+	serveCmd   = kingpin.Command("serve", "Serve a web UI and JSON API for analyzing uploaded binaries")
+	listenAddr = serveCmd.Flag("listen", "Address to listen on").Default("127.0.0.1:8080").String()
+	serveRoot  = serveCmd.Flag("root", "Directory whose binaries may be analyzed by path (default: uploads only)").String()
+	maxUpload  = serveCmd.Flag("max-upload", "Maximum upload size per request").Default("1GB").String()
+	diffCmd    = kingpin.Command("diff", "Compare the sizes of two binaries")
+	diffOld    = diffCmd.Arg("old", "Old binary").Required().String()
+	diffNew    = diffCmd.Arg("new", "New binary").Required().String()
+	depsCmd    = kingpin.Command("deps", "Attribute transitive dependency sizes to the direct dependencies that bring them in")
+	unusedCmd  = kingpin.Command("unused", "List go.mod requirements that contribute little or nothing to the binary")
+	unusedMin  = unusedCmd.Flag("min-size", "Report requirements linking fewer bytes than this").Default("10KB").String()
+	dupsCmd    = kingpin.Command("duplicates", "Find modules linked more than once (major versions or forks)")
+	genericsCmd   = kingpin.Command("generics", "Group generic instantiations by the function they come from")
+	genericsShown = genericsCmd.Flag("instances", "Largest instantiations to list per function").Default("3").Int()
+	embedsCmd     = kingpin.Command("embeds", "List files embedded with //go:embed per package")
+	filesCmd      = kingpin.Command("files", "Attribute code bytes to source files and directories using the pclntab")
+	filesLines    = filesCmd.Flag("lines", "Heaviest lines to list per file").Default("0").Int()
+	filesDirs     = filesCmd.Flag("dirs", "Roll files up per directory").Bool()
+	ownersCmd  = kingpin.Command("owners", "Sum linked bytes per team using an ownership file")
+	ownersFile = ownersCmd.Flag("file", "CODEOWNERS-style file mapping package patterns to teams").Default("WEIGHT_OWNERS").String()
 )
 
+func init() {
+	tuiCmd.Arg("packages", "Packages to build").StringVar(packages)
+	ownersCmd.Arg("packages", "Packages to build").StringVar(packages)
+	depsCmd.Arg("packages", "Packages to build").StringVar(packages)
+	unusedCmd.Arg("packages", "Packages to build").StringVar(packages)
+	dupsCmd.Arg("packages", "Packages to build").StringVar(packages)
+	genericsCmd.Arg("packages", "Packages to build").StringVar(packages)
+	embedsCmd.Arg("packages", "Packages to build").StringVar(packages)
+	filesCmd.Arg("packages", "Packages to build").StringVar(packages)
+}
+
 func main() {
 	kingpin.Version(fmt.Sprintf("%s (%s)", version, commit))
-	kingpin.Parse()
+	command := kingpin.Parse()
 	weight := pkg.NewGoWeight()
+	weight.Arch = *arch
+	weight.DebugFile = *debugFile
+	weight.DebugDirs = *debugDirs
+	weight.View = *view
+
+	switch command {
+	case tuiCmd.FullCommand():
+		browse(weight)
+		return
+	case serveCmd.FullCommand():
+		serve(weight)
+		return
+	case diffCmd.FullCommand():
+		diffBinaries(weight)
+		return
+	case ownersCmd.FullCommand():
+		reportOwners(weight)
+		return
+	case depsCmd.FullCommand():
+		reportDirectDependencies(weight)
+		return
+	case unusedCmd.FullCommand():
+		reportUnusedRequirements(weight)
+		return
+	case dupsCmd.FullCommand():
+		reportDuplicates(weight)
+		return
+	case genericsCmd.FullCommand():
+		reportGenerics(weight)
+		return
+	case embedsCmd.FullCommand():
+		reportEmbeds(weight)
+		return
+	case filesCmd.FullCommand():
+		reportSourceFiles(weight)
+		return
+	}
+
+	if *jsonOutput {
+		*format = "json"
+	}
+
+	if *format == "dot" {
+		writeDOTGraph(weight)
+		return
+	}
 
 	var modules []*pkg.ModuleEntry
+	var report *pkg.Report
+	var err error
 
-	if *binaryFile != "" {
-		modules = weight.ProcessBinary(*binaryFile)
+	if *binaryFile != "" && (*format == "text" || *format == "json") && !*listSymbols && *symbolPackage == "" {
+		// 通用二进制文件没有用 --arch 选择切片时按架构并排显示
+		var reports []*pkg.Report
+		if reports, err = weight.AnalyzeArches(*binaryFile); err == nil && len(reports) > 1 {
+			printArches(reports)
+			return
+		}
+		if err == nil {
+			report = reports[0]
+		}
+	} else if *binaryFile != "" {
+		if *arch == "" && !*listSymbols && *symbolPackage == "" {
+			requireSlice(*binaryFile)
+		}
+		report, err = weight.AnalyzeBinary(*binaryFile)
 	} else if *buildAnalysis {
 		// 使用构建过程分析模式
 		var pkgArgs []string
@@ -46,107 +158,714 @@ func main() {
 		}
 		modules = weight.AnalyzeBuildProcess(pkgArgs...)
 	} else {
-		if *buildTags != "" {
-			weight.BuildCmd = append(weight.BuildCmd, "-tags", *buildTags)
-		}
-		if *packages != "" {
-			weight.BuildCmd = append(weight.BuildCmd, *packages)
-		}
+		configureBuild(weight)
 
 		// 使用新的方法分析最终的二进制文件，而不是中间构建产物
-		modules = weight.BuildAndAnalyzeBinary()
+		report, err = weight.BuildAndAnalyze()
+	}
+	if err != nil {
+		log.Fatalf("Error analyzing binary: %v", err)
 	}
 
-	if *jsonOutput {
-		m, _ := json.Marshal(modules)
-		fmt.Print(string(m))
+	if *listSymbols || *symbolPackage != "" {
+		if report == nil {
+			log.Fatalf("--symbols and --package need a binary analysis, not --build-analysis")
+		}
+		printSymbols(report)
+		return
+	}
+
+	if report != nil && wantOrigins() {
+		classifyOrigins(report)
+	}
+	keep := keepModule()
+	grouping, rules := loadGrouping()
+	if rules != nil && len(*only) == 0 {
+		// 规则文件可以把标准库作为一个分组，所以保留全部模块（JSON 只在有 --only 时过滤，不受影响）
+		keep = nil
+	}
+
+	// 预算按分组后的条目检查，与输出格式无关
+	var entries []*pkg.ModuleEntry
+	if report != nil {
+		entries = report.GroupPackages(grouping, keep)
 	} else {
-		if *verbose {
-			// 详细输出 - 显示所有包
-			for _, module := range modules {
-				fmt.Printf("%8s %s\n", module.SizeHuman, module.Name)
+		entries = pkg.GroupEntries(modules, grouping)
+	}
+
+	switch *format {
+	case "json":
+		var m []byte
+		if report != nil {
+			// 版本化的报告结构，详细模式下包含每个包的符号
+			if !*verbose {
+				report = report.WithoutSymbols()
 			}
+			if len(*only) > 0 {
+				report.Modules = filterModules(report.Modules, keep)
+			}
+			m, _ = json.Marshal(report)
 		} else {
-			// 简略输出 - 合并相同顶级包
-			aggregatedModules := aggregateByTopLevelPackage(modules)
-			for _, module := range aggregatedModules {
-				fmt.Printf("%8s %s\n", module.SizeHuman, module.Name)
+			m, _ = json.Marshal(modules)
+		}
+		fmt.Print(string(m))
+	case "markdown":
+		err = pkg.WriteMarkdown(os.Stdout, entries)
+	case "csv":
+		err = pkg.WriteCSV(os.Stdout, entries, ',')
+	case "tsv":
+		err = pkg.WriteCSV(os.Stdout, entries, '\t')
+	default:
+		if *memory && report != nil {
+			printMemory(report, entries)
+			break
+		}
+		for _, module := range entries {
+			if *verbose && module.Metadata > 0 {
+				// 详细模式下显示其中类型描述符和 itab 占用的大小
+				fmt.Printf("%8s %s (%s type metadata)\n", module.SizeHuman, module.Name, humanize.Bytes(module.Metadata))
+				continue
+			}
+			fmt.Printf("%8s %s\n", module.SizeHuman, module.Name)
+		}
+		if report != nil {
+			printOriginTotals(report.OriginTotals(keep))
+			if report.Totals.Embedded > 0 {
+				fmt.Printf("%8s embedded files (//go:embed)\n", humanize.Bytes(report.Totals.Embedded))
+			}
+			if report.Totals.Strings > 0 {
+				fmt.Printf("%8s string literals (shared between packages, not attributed)\n", humanize.Bytes(report.Totals.Strings))
+			}
+			if d := report.DataSegments; d != nil {
+				fmt.Printf("%8s data segments (%d segments, not attributed)\n", d.SizeHuman, d.Count)
+			}
+			if report.View == pkg.ViewLogical {
+				fmt.Printf("%8s inlined code charged to the inlined functions' packages\n", humanize.Bytes(report.Totals.Inlined))
+			}
+			if report.Totals.ExportData > 0 {
+				fmt.Printf("%8s export data (__.PKGDEF)\n", humanize.Bytes(report.Totals.ExportData))
+			}
+			if len(report.Binary.Libraries) > 0 {
+				fmt.Printf("\ndynamic libraries: %s\n", strings.Join(report.Binary.Libraries, ", "))
 			}
 		}
 	}
+	if err != nil {
+		log.Fatalf("Error writing %s output: %v", *format, err)
+	}
+
+	if rules != nil {
+		violations := rules.CheckBudgets(entries)
+		for _, v := range violations {
+			fmt.Fprintf(os.Stderr, "budget exceeded: %s\n", v)
+		}
+		if len(violations) > 0 {
+			os.Exit(1)
+		}
+	}
 }
 
-// aggregateByTopLevelPackage 将相同顶级包的模块合并
-func aggregateByTopLevelPackage(modules []*pkg.ModuleEntry) []*pkg.ModuleEntry {
-	// 创建映射来存储聚合结果
-	aggregated := make(map[string]*pkg.ModuleEntry)
-	
-	for _, module := range modules {
-		topLevel := getTopLevelPackage(module.Name)
-		
-		if existing, exists := aggregated[topLevel]; exists {
-			// 如果已存在此顶级包，则累加大小
-			existing.Size += module.Size
-			existing.SizeHuman = humanize.Bytes(existing.Size)
-		} else {
-			// 否则创建新的聚合项
-			aggregated[topLevel] = &pkg.ModuleEntry{
-				Path:      topLevel,
-				Name:      topLevel,
-				Size:      module.Size,
-				SizeHuman: humanize.Bytes(module.Size),
+// printSymbols 列出最大的符号，或者 --package 指定的包中的所有符号
+func printSymbols(report *pkg.Report) {
+	var entries []*pkg.SymbolEntry
+	if *symbolPackage != "" {
+		var err error
+		if entries, err = report.PackageSymbols(*symbolPackage); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	} else {
+		entries = report.TopSymbols(*top)
+	}
+
+	if *format == "json" {
+		m, _ := json.Marshal(entries)
+		fmt.Print(string(m))
+		return
+	}
+	for _, e := range entries {
+		// 列出最大的符号时附上所属的包，C 符号还附上所在的翻译单元
+		var where []string
+		if *symbolPackage == "" {
+			where = append(where, e.Package)
+		}
+		if e.Unit != "" {
+			where = append(where, e.Unit)
+		}
+		line := fmt.Sprintf("%8s %-7s %-14s %s", e.SizeHuman, e.Kind, e.Section, e.Name)
+		if len(where) > 0 {
+			line += " (" + strings.Join(where, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+// wantOrigins 判断输出是否需要模块的来源分类：文本输出的小计、JSON 报告和 --only
+func wantOrigins() bool {
+	return len(*only) > 0 || *format == "text" || *format == "json"
+}
+
+// classifyOrigins 给报告中的模块标注来源分类，标准库来自 go list std，直接/间接依赖来自 go.mod
+func classifyOrigins(reports ...*pkg.Report) {
+	std, err := pkg.LoadStdPackages()
+	if err != nil {
+		log.Printf("Warning: %v; packages outside of known modules count as std", err)
+	}
+	for _, report := range reports {
+		requirements, err := readRequirements(report)
+		if err != nil && (*goModFile != "" || *binaryFile == "") {
+			log.Printf("Warning: %v; not telling direct from indirect dependencies", err)
+		}
+		report.ClassifyOrigins(requirements, std)
+	}
+}
+
+// readRequirements 读取主模块 go.mod 中的依赖：--go-mod 指定的文件，没有 -b 时是当前构建的模块的 go.mod
+func readRequirements(report *pkg.Report) ([]pkg.Requirement, error) {
+	path := *goModFile
+	if path == "" {
+		if *binaryFile != "" {
+			return nil, fmt.Errorf("pass --go-mod to read the requirements of %s", report.Build.MainModule.Path)
+		}
+		var err error
+		if path, err = pkg.FindGoMod(); err != nil {
+			return nil, err
+		}
+	}
+	return pkg.ReadGoMod(path, report.Build.MainModule.Path)
+}
+
+// keepModule 返回要显示的模块：--only 指定的来源；默认不显示标准库，因为它不是依赖
+func keepModule() func(*pkg.ModuleReport) bool {
+	if len(*only) == 0 {
+		return func(m *pkg.ModuleReport) bool { return m.Path != pkg.StdModule }
+	}
+	origins := make(map[string]bool)
+	for _, o := range *only {
+		origins[o] = true
+	}
+	return func(m *pkg.ModuleReport) bool { return origins[m.Origin] }
+}
+
+// filterModules 返回 keep 为真的模块
+func filterModules(modules []*pkg.ModuleReport, keep func(*pkg.ModuleReport) bool) []*pkg.ModuleReport {
+	var kept []*pkg.ModuleReport
+	for _, m := range modules {
+		if keep(m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// printOriginTotals 在文本输出末尾按来源分类打印小计
+func printOriginTotals(totals map[string]uint64) {
+	labels := []struct{ origin, label string }{
+		{pkg.OriginMain, "main module"},
+		{pkg.OriginDirect, "direct dependencies"},
+		{pkg.OriginIndirect, "indirect dependencies"},
+		{pkg.OriginDependency, "dependencies (no go.mod to tell direct from indirect)"},
+		{pkg.OriginStd, "standard library"},
+		{pkg.OriginCgo, "C code not owned by a Go package (<cgo>)"},
+		{pkg.OriginUnknown, "packages outside of known modules and std (<unknown>)"},
+	}
+	fmt.Println()
+	for _, l := range labels {
+		if size, ok := totals[l.origin]; ok {
+			fmt.Printf("%8s %s\n", humanize.Bytes(size), l.label)
+		}
+	}
+}
+
+// requireSlice 拒绝用只有一列大小的表格格式输出通用二进制文件，要求用 --arch 选择切片
+func requireSlice(path string) {
+	arches, err := pkg.FatArches(path)
+	if err != nil {
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	if len(arches) > 1 {
+		log.Fatalf("--format %s shows a single architecture; pass --arch to pick a slice of %s (%s)", *format, path, strings.Join(arches, ", "))
+	}
+}
+
+// printArches 按架构并排显示通用二进制文件中每个切片的分析结果，JSON 输出把每个切片的报告放在 slices 中
+func printArches(reports []*pkg.Report) {
+	if wantOrigins() {
+		classifyOrigins(reports...)
+	}
+	keep := keepModule()
+	if *format == "json" {
+		for i, report := range reports {
+			if !*verbose {
+				report = report.WithoutSymbols()
 			}
+			if len(*only) > 0 {
+				report.Modules = filterModules(report.Modules, keep)
+			}
+			reports[i] = report
 		}
+		m, _ := json.Marshal(pkg.UniversalReport(reports))
+		fmt.Print(string(m))
+		return
 	}
-	
-	// 转换为切片并按大小排序
-	var result []*pkg.ModuleEntry
-	for _, module := range aggregated {
-		result = append(result, module)
+
+	grouping, _ := loadGrouping()
+	for _, report := range reports {
+		fmt.Printf("%10s", report.Binary.Arch)
 	}
-	
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Size > result[j].Size
-	})
-	
-	return result
+	fmt.Println()
+	for _, row := range pkg.ArchRows(reports, grouping, keep) {
+		for _, size := range row.Sizes {
+			fmt.Printf("%10s", humanize.Bytes(size))
+		}
+		fmt.Printf(" %s\n", row.Name)
+	}
+	fmt.Println()
+	for _, report := range reports {
+		fmt.Printf("%10s", humanize.Bytes(report.Binary.Size))
+	}
+	fmt.Println(" slice size")
+	for _, report := range reports {
+		fmt.Printf("%10s", humanize.Bytes(report.Totals.Attributed))
+	}
+	fmt.Println(" attributed")
 }
 
-// getTopLevelPackage 获取顶级包名
-func getTopLevelPackage(fullPackageName string) string {
-	// 处理标准库包
-	if strings.HasPrefix(fullPackageName, "runtime") {
-		return "runtime"
+// printMemory 把静态内存和磁盘大小并排显示，按静态内存降序排列
+// 零初始化的 .bss 不占文件空间，但运行时同样要占内存
+func printMemory(report *pkg.Report, entries []*pkg.ModuleEntry) {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Memory > entries[j].Memory })
+	fmt.Printf("%8s %8s\n", "disk", "memory")
+	for _, module := range entries {
+		fmt.Printf("%8s %8s %s\n", module.SizeHuman, humanize.Bytes(module.Memory), module.Name)
 	}
-	if strings.HasPrefix(fullPackageName, "internal/") {
-		return "internal/*"
+	fmt.Printf("\n%8s %8s total\n", humanize.Bytes(report.Totals.Attributed), humanize.Bytes(report.Totals.Memory))
+}
+
+// loadGrouping 根据 --group-by 和 --rules 决定聚合方式
+// 默认按模块显示；指定规则文件时按规则分组
+func loadGrouping() (pkg.Grouping, *pkg.Rules) {
+	if *rulesFile != "" {
+		if *groupBy != "" && *groupBy != pkg.GroupByRules {
+			log.Fatalf("--rules and --group-by %s cannot be used together", *groupBy)
+		}
+		rules, err := pkg.LoadRules(*rulesFile)
+		if err != nil {
+			log.Fatalf("Error loading rules: %v", err)
+		}
+		return pkg.Grouping{Mode: pkg.GroupByRules, Rules: rules}, rules
 	}
-	if strings.HasPrefix(fullPackageName, "vendor/") {
-		return "vendor/*"
+
+	spec := *groupBy
+	if spec == "" {
+		spec = pkg.GroupByModule
 	}
-	
-	// 处理第三方包
-	if strings.Contains(fullPackageName, ".") || strings.Contains(fullPackageName, "/") {
-		parts := strings.Split(fullPackageName, "/")
-		if len(parts) > 0 {
-			// 如果是域名开头的包（如 github.com、golang.org 等）
-			if strings.Contains(parts[0], ".") {
-				// 对于域名，返回域名+用户名（如 github.com/user）
-				if len(parts) >= 2 {
-					return strings.Join(parts[:2], "/")
-				}
-				return parts[0]
-			}
-			
-			// 对于非域名开头的标准格式包，返回前两部分
-			if len(parts) >= 2 {
-				return strings.Join(parts[:2], "/")
-			}
-			
-			return parts[0]
+	if spec == pkg.GroupByRules {
+		log.Fatalf("--group-by rules requires --rules FILE")
+	}
+	grouping, err := pkg.ParseGrouping(spec)
+	if err != nil {
+		log.Fatalf("Invalid --group-by: %v", err)
+	}
+	return grouping, nil
+}
+
+// configureBuild 把构建标签和包参数加入构建命令
+func configureBuild(weight *pkg.GoWeight) {
+	if *buildTags != "" {
+		weight.BuildCmd = append(weight.BuildCmd, "-tags", *buildTags)
+	}
+	if *buildMode != "" {
+		weight.BuildCmd = append(weight.BuildCmd, "-buildmode="+*buildMode)
+	}
+	if *packages != "" {
+		weight.BuildCmd = append(weight.BuildCmd, *packages)
+	}
+}
+
+// prepareBinary 返回要分析的二进制文件路径；没有指定 -b 时先构建项目
+// listArgs 是加载导入图时传给 go list 的参数，cleanup 用于删除临时构建的二进制文件
+func prepareBinary(weight *pkg.GoWeight) (binaryPath string, listArgs []string, cleanup func()) {
+	if *binaryFile != "" {
+		return *binaryFile, nil, func() {}
+	}
+
+	configureBuild(weight)
+	binaryPath = "goweight-temp-binary"
+	if err := weight.BuildBinary(binaryPath); err != nil {
+		log.Fatalf("Error building binary: %v", err)
+	}
+	return binaryPath, weight.BuildArgs(), func() { pkg.RemoveBinary(binaryPath) } // 清理临时文件
+}
+
+// browse 在交互式终端界面中浏览分析结果
+func browse(weight *pkg.GoWeight) {
+	binaryPath, listArgs, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	loadGraph := func() (*pkg.ImportGraph, error) {
+		return pkg.LoadImportGraph(report, listArgs...)
+	}
+	if err := runTUI(report, loadGraph); err != nil {
+		log.Printf("Error running tui: %v", err)
+	}
+}
+
+// serve 启动本地 HTTP 服务，提供分析 API 和浏览界面
+func serve(weight *pkg.GoWeight) {
+	limit, err := humanize.ParseBytes(*maxUpload)
+	if err != nil {
+		log.Fatalf("Invalid --max-upload %q: %v", *maxUpload, err)
+	}
+
+	opts := pkg.ServerOptions{Root: *serveRoot, MaxUpload: int64(limit)}
+	if *rulesFile != "" {
+		if opts.Rules, err = pkg.LoadRules(*rulesFile); err != nil {
+			log.Fatalf("Error loading rules: %v", err)
 		}
 	}
-	
-	// 默认返回原名称
-	return fullPackageName
+	handler := weight.Handler(opts)
+	log.Printf("goweight serving on http://%s", *listenAddr)
+	log.Fatal(http.ListenAndServe(*listenAddr, handler))
+}
+
+// writeDOTGraph 输出实际链接进二进制文件的包导入图（Graphviz DOT 格式）
+func writeDOTGraph(weight *pkg.GoWeight) {
+	minSize, err := humanize.ParseBytes(*dotMinSize)
+	if err != nil {
+		log.Fatalf("Invalid --dot-min-size %q: %v", *dotMinSize, err)
+	}
+
+	binaryPath, listArgs, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	graph, err := pkg.LoadImportGraph(report, listArgs...)
+	if err != nil {
+		log.Printf("Error loading import graph: %v", err)
+		return
+	}
+
+	opts := pkg.DOTOptions{ClusterByModule: *dotCluster, MinSize: minSize}
+	if err := pkg.WriteDOT(os.Stdout, graph, opts); err != nil {
+		log.Printf("Error writing dot output: %v", err)
+	}
+}
+
+// diffBinaries 比较两个二进制文件，按当前的聚合方式（或规则文件）列出大小变化
+func diffBinaries(weight *pkg.GoWeight) {
+	grouping, _ := loadGrouping()
+
+	oldReport, err := weight.AnalyzeBinary(*diffOld)
+	if err != nil {
+		log.Fatalf("Error analyzing %s: %v", *diffOld, err)
+	}
+	newReport, err := weight.AnalyzeBinary(*diffNew)
+	if err != nil {
+		log.Fatalf("Error analyzing %s: %v", *diffNew, err)
+	}
+
+	diff := pkg.DiffReports(oldReport, newReport)
+	diff.Groups = pkg.DiffGroups(oldReport, newReport, grouping)
+
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(diff)
+		fmt.Print(string(m))
+		return
+	}
+
+	fmt.Printf("%s -> %s: %s\n", diff.Old.Path, diff.New.Path, diff.DeltaHuman)
+	for _, e := range diff.Groups {
+		if e.Status == pkg.DiffUnchanged && !*verbose {
+			continue
+		}
+		fmt.Printf("%9s %8s %s (%s)\n", e.DeltaHuman, humanize.Bytes(e.NewSize), e.Name, e.Status)
+	}
+}
+
+// reportOwners 按所有权文件汇总每个团队的链接大小，以及只被该团队导入的第三方模块
+func reportOwners(weight *pkg.GoWeight) {
+	owners, err := pkg.LoadOwners(*ownersFile)
+	if err != nil {
+		log.Fatalf("Error loading ownership file: %v", err)
+	}
+
+	binaryPath, listArgs, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	graph, err := pkg.LoadImportGraph(report, listArgs...)
+	if err != nil {
+		log.Printf("Warning: loading import graph, sole importers are not shown: %v", err)
+		graph = nil
+	}
+
+	result := owners.AttributeOwners(report, graph)
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(result)
+		fmt.Print(string(m))
+		return
+	}
+	for _, r := range result {
+		fmt.Printf("%8s %s (%d packages)\n", r.SizeHuman, r.Owner, len(r.Packages))
+		for _, m := range r.SoleModules {
+			fmt.Printf("%8s   only imported by %s: %s\n", m.SizeHuman, r.Owner, m.Path)
+		}
+	}
+}
+
+// reportDirectDependencies 列出每个直接依赖独占和共享的传递依赖，以及去掉它能省下的大小
+func reportDirectDependencies(weight *pkg.GoWeight) {
+	binaryPath, listArgs, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	graph, err := pkg.LoadImportGraph(report, listArgs...)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error loading import graph: %v", err)
+	}
+	requirements, err := readRequirements(report)
+	if err != nil {
+		log.Printf("Warning: %v; treating modules imported by the main module as direct dependencies", err)
+	}
+
+	result := graph.AttributeDirectDependencies(report, requirements)
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(result)
+		fmt.Print(string(m))
+		return
+	}
+	for _, dep := range result {
+		fmt.Printf("%8s %s (own %s, %d exclusive, %d shared modules)\n",
+			dep.AttributableHuman, dep.Path, dep.SizeHuman, len(dep.Exclusive), len(dep.Shared))
+		for _, m := range dep.Exclusive {
+			fmt.Printf("%8s   only via %s: %s\n", m.SizeHuman, dep.Path, m.Path)
+		}
+		if *verbose {
+			for _, m := range dep.Shared {
+				fmt.Printf("%8s   shared: %s\n", m.SizeHuman, m.Path)
+			}
+		}
+	}
+}
+
+// reportUnusedRequirements 列出 go.mod 中没有被链接或链接得很少的依赖，作为清理的候选
+func reportUnusedRequirements(weight *pkg.GoWeight) {
+	threshold, err := humanize.ParseBytes(*unusedMin)
+	if err != nil {
+		log.Fatalf("Invalid --min-size %q: %v", *unusedMin, err)
+	}
+
+	binaryPath, _, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	if report.Source == pkg.SourceEstimate {
+		log.Printf("Warning: no symbols in %s, sizes are estimated from the module cache", binaryPath)
+	}
+	requirements, err := readRequirements(report)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error reading go.mod: %v", err)
+	}
+
+	result := report.UnusedRequirements(requirements, threshold)
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(result)
+		fmt.Print(string(m))
+		return
+	}
+	reasons := map[string]string{
+		pkg.UnusedNotBuilt:      "not in the build (test or tool only)",
+		pkg.UnusedNotLinked:     "built but nothing linked",
+		pkg.UnusedSinglePackage: "only one small package linked",
+		pkg.UnusedSmall:         "under " + humanize.Bytes(threshold),
+	}
+	for _, u := range result {
+		indirect := ""
+		if u.Indirect {
+			indirect = " // indirect"
+		}
+		fmt.Printf("%8s %s %s%s: %s", u.SizeHuman, u.Path, u.Version, indirect, reasons[u.Reason])
+		if u.Package != "" {
+			fmt.Printf(" (%s)", u.Package)
+		}
+		fmt.Println()
+	}
+}
+
+// reportDuplicates 列出被链接了多份的模块、它们的总大小和每份拷贝的导入链
+func reportDuplicates(weight *pkg.GoWeight) {
+	binaryPath, listArgs, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	graph, err := pkg.LoadImportGraph(report, listArgs...)
+	if err != nil {
+		log.Printf("Warning: loading import graph, import chains are not shown: %v", err)
+		graph = nil
+	}
+
+	groups := report.Duplicates(graph)
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(groups)
+		fmt.Print(string(m))
+		return
+	}
+	if len(groups) == 0 {
+		fmt.Println("no duplicate modules")
+		return
+	}
+	for _, g := range groups {
+		fmt.Printf("%8s %s (%d copies)\n", g.SizeHuman, g.Module, len(g.Copies))
+		for _, c := range g.Copies {
+			replace := ""
+			if c.Replace != nil {
+				replace = fmt.Sprintf(" => %s %s", c.Replace.Path, c.Replace.Version)
+			}
+			fmt.Printf("%8s   %s %s%s\n", c.SizeHuman, c.Path, c.Version, replace)
+			if len(c.ImportChain) > 0 {
+				fmt.Printf("%8s     via %s\n", "", strings.Join(c.ImportChain, " -> "))
+			}
+		}
+	}
+}
+
+// reportGenerics 列出实例化后占用最多空间的泛型函数
+func reportGenerics(weight *pkg.GoWeight) {
+	binaryPath, _, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+
+	generics := report.GenericInstantiations()
+	if *top > 0 && len(generics) > *top {
+		generics = generics[:*top]
+	}
+	for _, g := range generics {
+		if *genericsShown >= 0 && len(g.Largest) > *genericsShown {
+			g.Largest = g.Largest[:*genericsShown]
+		}
+	}
+
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(generics)
+		fmt.Print(string(m))
+		return
+	}
+	for _, g := range generics {
+		fmt.Printf("%8s %s (%d instantiations, %d dictionaries)\n", g.SizeHuman, g.Function, g.Instantiations, g.Dictionaries)
+		for _, sym := range g.Largest {
+			fmt.Printf("%8s   %s\n", humanize.Bytes(sym.Size), sym.Name)
+		}
+	}
+}
+
+// reportEmbeds 列出每个包嵌入的文件：embed.FS 的文件表来自二进制文件，
+// 嵌入为 string 或 []byte 的文件来自 go list
+func reportEmbeds(weight *pkg.GoWeight) {
+	binaryPath, listArgs, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	embeds, err := weight.AnalyzeEmbeds(report, listArgs...)
+	if err != nil {
+		log.Printf("Warning: go list failed, only embed.FS variables are shown: %v", err)
+	}
+
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(embeds)
+		fmt.Print(string(m))
+		return
+	}
+	for _, e := range embeds {
+		fmt.Printf("%8s %s (%d files)\n", e.SizeHuman, e.Package, len(e.Files))
+		for i, f := range e.Files {
+			if i == *top && *top > 0 {
+				fmt.Printf("%8s   ... %d more\n", "", len(e.Files)-i)
+				break
+			}
+			variable := ""
+			if f.Variable != "" {
+				variable = " (" + f.Variable + ")"
+			}
+			fmt.Printf("%8s   %s%s\n", f.SizeHuman, f.Name, variable)
+		}
+	}
+}
+
+// reportSourceFiles 按源文件（--dirs 时按目录）列出代码大小，--lines 列出每个文件中最大的几行
+func reportSourceFiles(weight *pkg.GoWeight) {
+	binaryPath, _, cleanup := prepareBinary(weight)
+	defer cleanup()
+
+	report, err := weight.AnalyzeBinary(binaryPath)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error analyzing binary: %v", err)
+	}
+	sources, err := weight.AnalyzeSources(report, *filesLines > 0)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Error reading line tables: %v", err)
+	}
+	if *top > 0 {
+		sources.Files = sources.Files[:min(*top, len(sources.Files))]
+		sources.Dirs = sources.Dirs[:min(*top, len(sources.Dirs))]
+	}
+	for _, f := range sources.Files {
+		f.Lines = f.Lines[:min(*filesLines, len(f.Lines))]
+	}
+
+	if *jsonOutput || *format == "json" {
+		m, _ := json.Marshal(sources)
+		fmt.Print(string(m))
+		return
+	}
+	if *filesDirs {
+		for _, d := range sources.Dirs {
+			fmt.Printf("%8s %s (%d files)\n", d.SizeHuman, d.Path, d.Files)
+		}
+	} else {
+		for _, f := range sources.Files {
+			fmt.Printf("%8s %s\n", f.SizeHuman, f.Path)
+			for _, l := range f.Lines {
+				fmt.Printf("%8s   :%d\n", l.SizeHuman, l.Line)
+			}
+		}
+	}
+	fmt.Printf("\n%8s without line information\n", humanize.Bytes(sources.Unattributed))
 }
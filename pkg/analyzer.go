@@ -20,12 +20,23 @@ type ModuleEntry struct {
 	Path      string `json:"path"`
 	Name      string `json:"name"`
 	Version   string `json:"version,omitempty"`
+	Origin    string `json:"origin,omitempty"`
 	Size      uint64 `json:"size"`
 	SizeHuman string `json:"size_human"`
+	Metadata  uint64 `json:"metadata,omitempty"`
+	Memory    uint64 `json:"memory,omitempty"`
 }
 
 type GoWeight struct {
 	BuildCmd []string
+	// Arch 选择分析 Mach-O 通用二进制文件中的哪个切片，为空时选当前平台的架构或第一个切片
+	Arch string
+	// DebugFile 和 DebugDirs 指定剥离出去的 ELF 调试信息：直接给出调试文件，
+	// 或者在这些目录中按 GNU build ID 和 .gnu_debuglink 查找
+	DebugFile string
+	DebugDirs []string
+	// View 是 ViewLogical 时把内联代码计入被内联函数的包
+	View string
 }
 
 func NewGoWeight() *GoWeight {
@@ -86,26 +97,33 @@ func (g *GoWeight) AnalyzeBuildProcess(packages ...string) []*ModuleEntry {
 // parseBuildOutput 解析构建输出来获取包信息
 func parseBuildOutput(output string) []*ModuleEntry {
 	var modules []*ModuleEntry
+	var work string
 	
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
+		if w, ok := strings.CutPrefix(line, "WORK="); ok {
+			work = strings.TrimSpace(w)
+		}
+
 		// 查找编译命令，如 "/path/to/compile -o $WORK/b001/_pkg_.a -trimpath [...]" 或 "compile -o $WORK/b001/_pkg_.a [...]"
 		if strings.Contains(line, "/compile") && strings.Contains(line, "-o") && strings.Contains(line, "_pkg_.a") {
 			// 提取输出文件路径
 			parts := strings.Fields(line)
-			var outputFile string
+			var outputFile, importPath string
 			for i, part := range parts {
 				if part == "-o" && i+1 < len(parts) {
 					outputFile = parts[i+1]
-					break
+				} else if part == "-p" && i+1 < len(parts) {
+					importPath = parts[i+1]
 				}
 			}
 			
 			if outputFile != "" {
-				// 获取文件大小
-				// 由于 WORK 目录是临时的，我们无法直接访问文件
-				// 因此，我们只记录包名，大小暂时设置为0
-				packageName := extractPackageNameFromWorkDir(outputFile)
+				// 大小在构建结束后解析归档文件得到
+				packageName := importPath
+				if packageName == "" {
+					packageName = extractPackageNameFromWorkDir(outputFile)
+				}
 				
 				// 检查是否已经存在相同的包
 				exists := false
@@ -120,8 +138,8 @@ func parseBuildOutput(output string) []*ModuleEntry {
 					module := &ModuleEntry{
 						Path:      outputFile,
 						Name:      packageName,
-						Size:      0, // 无法获取临时文件大小
-						SizeHuman: "0 B", // 无法获取临时文件大小
+						Size:      0,
+						SizeHuman: "0 B",
 					}
 					modules = append(modules, module)
 				}
@@ -134,7 +152,6 @@ func parseBuildOutput(output string) []*ModuleEntry {
 			if len(parts) >= 3 {
 				packFile := parts[2]
 				if strings.HasSuffix(packFile, "_pkg_.a") {
-					// 无法获取临时文件大小
 					packageName := extractPackageNameFromWorkDir(packFile)
 					
 					// 检查是否已经存在相同的包
@@ -150,8 +167,8 @@ func parseBuildOutput(output string) []*ModuleEntry {
 						module := &ModuleEntry{
 							Path:      packFile,
 							Name:      packageName,
-							Size:      0, // 无法获取临时文件大小
-							SizeHuman: "0 B", // 无法获取临时文件大小
+							Size:      0,
+							SizeHuman: "0 B",
 						}
 						modules = append(modules, module)
 					}
@@ -160,6 +177,21 @@ func parseBuildOutput(output string) []*ModuleEntry {
 		}
 	}
 	
+	// -work 保留了工作目录，解析其中的归档文件得到链接之前的代码和数据大小
+	if work != "" {
+		for _, m := range modules {
+			a, err := readGoArchive(strings.Replace(m.Path, "$WORK", work, 1))
+			if err != nil {
+				continue
+			}
+			if a.Package != "" {
+				m.Name = a.Package
+			}
+			m.Size = a.Size()
+			m.SizeHuman = humanize.Bytes(m.Size)
+		}
+	}
+
 	// 按大小排序
 	sort.Slice(modules, func(i, j int) bool {
 		return modules[i].Size > modules[j].Size
@@ -269,6 +301,10 @@ func processModule(line string) *ModuleEntry {
 		return nil
 	}
 	sz := uint64(stat.Size())
+	// 能解析的归档文件使用其中符号的代码和数据大小，而不是包含导出数据的文件大小
+	if a, err := readGoArchive(path); err == nil {
+		sz = a.Size()
+	}
 
 	return &ModuleEntry{
 		Path:      path,
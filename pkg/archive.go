@@ -0,0 +1,349 @@
+package pkg
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"go/version"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// 归档文件和目标文件的格式
+const (
+	FormatArchive = "archive" // Go 归档文件（_pkg_.a、GOCACHE 中的 .a、-buildmode=archive）
+	FormatObject  = "object"  // 单独的 Go 目标文件（go tool compile -linkobj）
+)
+
+// SourceObject 表示符号大小取自 Go 目标文件中的符号定义（链接之前的大小）
+const SourceObject = "object"
+
+const (
+	arMagic       = "!<arch>\n"
+	arHeaderSize  = 60
+	goObjectMagic = "go object "
+	goobjMagic    = "\x00go120ld" // cmd/internal/goobj 的目标文件格式，Go 1.20 起未变
+)
+
+// goobj 文件头之后是各个块的偏移，符号定义占 BlkSymdef 到 BlkNonpkgref 之间的四个块
+const (
+	goobjBlkSymdef    = 3
+	goobjBlkNonpkgref = 7
+	goobjOffsets      = len(goobjMagic) + 8 + 4 // 魔数、指纹和标志之后
+	goobjSymSize      = 8 + 2 + 1 + 1 + 1 + 4 + 4
+)
+
+// goArchive 是从 Go 归档文件或目标文件中读出的包
+type goArchive struct {
+	Format string
+	// Package 是包的导入路径，取自 go:cuinfo.packagename.<包> 符号
+	Package   string
+	GoVersion string
+	GOOS      string
+	GOARCH    string
+	// ExportData 是 __.PKGDEF 成员（导出数据）的大小
+	ExportData uint64
+	Symbols    []Symbol
+	// Info 是 c-archive 中 go.o 记录的构建信息，普通的 Go 归档文件没有
+	Info *buildinfo.BuildInfo
+	// Embeds 是 c-archive 的 go.o 中 embed.FS 变量嵌入的文件
+	Embeds []*EmbedReport
+}
+
+// isGoArchive 判断文件是不是 ar 归档文件或 Go 目标文件
+func isGoArchive(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	// 没有成员的归档文件只有 8 字节的魔数，比目标文件的魔数短
+	head := make([]byte, len(goObjectMagic))
+	n, _ := f.ReadAt(head, 0)
+	head = head[:n]
+	return strings.HasPrefix(string(head), arMagic) || string(head) == goObjectMagic
+}
+
+// readGoArchive 读取 Go 归档文件的各个成员：__.PKGDEF 只统计大小，_go_.o 等 Go 目标文件
+// 读出符号定义，cgo 和汇编生成的 ELF 目标文件读出符号表
+func readGoArchive(path string) (*goArchive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &goArchive{Format: FormatObject}
+	if !bytes.HasPrefix(data, []byte(arMagic)) {
+		if err := a.readMember(path, data); err != nil {
+			return nil, err
+		}
+	} else {
+		a.Format = FormatArchive
+		if err := a.readArchive(path, data); err != nil {
+			return nil, err
+		}
+	}
+	if len(a.Symbols) == 0 {
+		return nil, fmt.Errorf("no symbols found in %s", path)
+	}
+	for i := range a.Symbols {
+		sym := &a.Symbols[i]
+		sym.Kind = symbolKind(sym.Name, sym.Section)
+		sym.Generic = genericOrigin(sym.Name, sym.Kind)
+	}
+	return a, nil
+}
+
+// Size 是归档文件中所有符号的代码和数据大小之和
+func (a *goArchive) Size() uint64 {
+	var size uint64
+	for _, sym := range a.Symbols {
+		size += sym.Size
+	}
+	return size
+}
+
+// readArchive 依次读取 ar 归档文件中的成员
+func (a *goArchive) readArchive(path string, data []byte) error {
+	for off := len(arMagic); off+arHeaderSize <= len(data); {
+		header := data[off : off+arHeaderSize]
+		name := strings.TrimSuffix(strings.TrimSpace(string(header[:16])), "/")
+		size, err := strconv.Atoi(strings.TrimSpace(string(header[48:58])))
+		if err != nil || size < 0 || off+arHeaderSize+size > len(data) {
+			return fmt.Errorf("%s: malformed archive member header at offset %d", path, off)
+		}
+		member := data[off+arHeaderSize : off+arHeaderSize+size]
+		if name == "__.PKGDEF" {
+			a.ExportData += uint64(size)
+			a.readHeader(member)
+		} else if err := a.readMember(name, member); err != nil {
+			return fmt.Errorf("%s(%s): %w", path, name, err)
+		}
+		off += arHeaderSize + size + size%2 // 成员按偶数字节对齐
+	}
+	return nil
+}
+
+// readMember 读取一个 Go 目标文件或 ELF 目标文件，其他成员（如 preferlinkext）忽略
+func (a *goArchive) readMember(name string, data []byte) error {
+	switch {
+	case bytes.HasPrefix(data, []byte(goObjectMagic)):
+		a.readHeader(data)
+		i := bytes.Index(data, []byte(goobjMagic))
+		if i < 0 {
+			// 只有导出数据的目标文件（如 -linkobj 之外的 .o 中的 __.PKGDEF 部分）
+			a.ExportData += uint64(len(data))
+			return nil
+		}
+		return a.readGoobj(data[i:])
+	case bytes.HasPrefix(data, []byte(elf.ELFMAG)):
+		f, err := elf.NewFile(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if info := objectBuildInfo(f); info != nil {
+			a.Info = info
+			if a.Embeds, err = objectEmbeds(f, info.Path); err != nil {
+				log.Printf("Warning: reading embed.FS tables in %s: %v", name, err)
+			}
+		}
+		syms, _ := f.Symbols()
+		var foreign []Symbol
+		for _, sym := range syms {
+			if sym.Size == 0 || int(sym.Section) >= len(f.Sections) || sym.Section == elf.SHN_UNDEF {
+				continue
+			}
+			section := f.Sections[sym.Section]
+			if section.Type == elf.SHT_NOBITS || section.Flags&elf.SHF_ALLOC == 0 {
+				continue
+			}
+			s := Symbol{Name: sym.Name, Size: sym.Size, Address: sym.Value, Section: section.Name}
+			if s.Package = extractPackageFromSymbol(sym.Name); s.Package != "" {
+				a.Symbols = append(a.Symbols, s)
+			} else {
+				foreign = append(foreign, s)
+			}
+		}
+		a.Symbols = append(a.Symbols, a.foreignSymbols(name, f, foreign)...)
+	}
+	return nil
+}
+
+// foreignSymbols 归属 ELF 目标文件中不带包名的符号
+// c-archive 的 go.o 是链接好的 Go 代码，按 DWARF 编译单元归属；其他成员是 cgo 编译的 C 代码，
+// 一个目标文件就是一个翻译单元，整体归入拥有它的包（不能确定时归入 <cgo>）
+func (a *goArchive) foreignSymbols(member string, f *elf.File, foreign []Symbol) []Symbol {
+	if len(foreign) == 0 {
+		return nil
+	}
+	d, _ := f.DWARF()
+	if member == "go.o" && d != nil {
+		return cgoSymbols(foreign, a.Symbols, d, f.ByteOrder)
+	}
+
+	owner, unit := a.Package, member
+	index := newCgoIndex(a.Symbols)
+	for _, sym := range foreign {
+		if pkg := index.owner(sym.Name); pkg != "" {
+			owner = pkg
+			break
+		}
+	}
+	if d != nil {
+		for _, u := range readCompUnits(d, f.ByteOrder) {
+			if !u.golang {
+				unit = u.unit()
+				if owner == "" {
+					owner = cgoUnitOwner(u, index.owners)
+				}
+				break
+			}
+		}
+	}
+	if owner == "" {
+		owner = CgoPackage
+	}
+	for i := range foreign {
+		foreign[i].Package, foreign[i].Unit = owner, unit
+	}
+	return foreign
+}
+
+// readHeader 解析目标文件的第一行 "go object <GOOS> <GOARCH> <版本> ..."
+func (a *goArchive) readHeader(data []byte) {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	fields := strings.Fields(strings.TrimPrefix(string(line), goObjectMagic))
+	if len(fields) >= 3 {
+		a.GOOS, a.GOARCH, a.GoVersion = fields[0], fields[1], fields[2]
+	}
+}
+
+// readGoobj 读出 goobj 格式中所有符号定义的名字、种类和大小
+func (a *goArchive) readGoobj(b []byte) error {
+	if len(b) < goobjOffsets+4*(goobjBlkNonpkgref+1) {
+		return fmt.Errorf("truncated Go object file")
+	}
+	offset := func(blk int) uint32 { return binary.LittleEndian.Uint32(b[goobjOffsets+4*blk:]) }
+	start, end := offset(goobjBlkSymdef), offset(goobjBlkNonpkgref)
+	if start > end || int(end) > len(b) {
+		return fmt.Errorf("malformed Go object file")
+	}
+	sections := goobjSections(a.GoVersion)
+
+	var symbols []Symbol
+	for off := start; off+goobjSymSize <= end; off += goobjSymSize {
+		sym := b[off : off+goobjSymSize]
+		nameLen, nameOff := binary.LittleEndian.Uint32(sym), binary.LittleEndian.Uint32(sym[4:])
+		if uint64(nameOff)+uint64(nameLen) > uint64(len(b)) {
+			continue
+		}
+		name := string(b[nameOff : nameOff+nameLen])
+		if p, ok := strings.CutPrefix(name, "go:cuinfo.packagename."); ok {
+			a.Package = p
+		}
+		section, ok := sections[sym[10]]
+		size := binary.LittleEndian.Uint32(sym[13:])
+		if !ok || size == 0 || name == "" {
+			continue // DWARF 以及 bss 中不占文件空间的符号
+		}
+		symbols = append(symbols, Symbol{Name: name, Size: uint64(size), Section: section})
+	}
+	for i := range symbols {
+		symbols[i].Package = extractPackageFromSymbol(symbols[i].Name)
+		if symbols[i].Package == "" {
+			// go:string.*、gclocals 等由编译器生成的内容符号属于定义它们的包
+			symbols[i].Package = a.Package
+		}
+	}
+	a.Symbols = append(a.Symbols, symbols...)
+	return nil
+}
+
+// goobjSections 把 cmd/internal/objabi.SymKind 映射到链接后所在的节
+// Go 1.24 加入 FIPS 相关的种类后编号发生了变化
+func goobjSections(goVersion string) map[byte]string {
+	if goVersion != "" && version.Compare(goVersion, "go1.24") < 0 {
+		return map[byte]string{1: ".text", 2: ".rodata", 3: ".noptrdata", 4: ".data"}
+	}
+	return map[byte]string{
+		1: ".text", 2: ".text",
+		3: ".rodata", 4: ".rodata",
+		5: ".noptrdata", 6: ".noptrdata",
+		7: ".data", 8: ".data",
+	}
+}
+
+// analyzeArchive 按包和符号统计归档文件中链接之前的代码和数据大小
+func analyzeArchive(path string, size uint64) (*Report, error) {
+	a, err := readGoArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		SchemaVersion: ReportSchemaVersion,
+		Binary:        BinaryInfo{Path: path, Size: size, Format: a.Format},
+		Build:         BuildMetadata{GoVersion: a.GoVersion, GOOS: a.GOOS, GOARCH: a.GOARCH, Path: a.Package},
+		Source:        SourceObject,
+	}
+
+	var modules []*ModuleReport
+	mainPath := ""
+	if a.Info != nil {
+		// c-archive：和可执行文件一样按 buildinfo 中的模块归属
+		report.Build = buildMetadata(a.Info)
+		modules, mainPath = buildModules(a.Info), a.Info.Path
+	} else if module := archiveModule(a.Package); module != nil {
+		// 被分析的包所在的模块作为主模块
+		module.Main = true
+		modules = append(modules, module)
+		report.Build.MainModule = ModuleVersion{Path: module.Path, Version: module.Version}
+	}
+	if a.Info == nil {
+		modules = append(modules, &ModuleReport{Path: StdModule})
+	}
+
+	modules = attributeSymbols(modules, a.Symbols, nil, mainPath)
+	for _, module := range modules {
+		report.Totals.Attributed += module.Size
+		report.Totals.Packages += len(module.Packages)
+	}
+	report.Totals.Symbols = len(a.Symbols)
+	report.Totals.ExportData = a.ExportData
+	report.Embeds = a.Embeds
+	for _, e := range a.Embeds {
+		report.Totals.Embedded += e.Size
+	}
+	report.setModules(modules)
+	return report, nil
+}
+
+// archiveModule 用 go list 找出包所在的模块，标准库包返回 nil。
+// 包路径来自归档文件本身，不是合法导入路径的不交给 go list
+func archiveModule(pkgPath string) *ModuleReport {
+	if pkgPath == "" {
+		return nil
+	}
+	if module.CheckImportPath(pkgPath) != nil {
+		return &ModuleReport{Path: pkgPath}
+	}
+	out, err := exec.Command("go", "list", "-f", "{{with .Module}}{{.Path}} {{.Version}}{{end}}", "--", pkgPath).Output()
+	if err != nil {
+		// 不在当前构建环境中的包，用包路径本身代表它的模块
+		return &ModuleReport{Path: pkgPath}
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return nil
+	}
+	m := &ModuleReport{Path: fields[0]}
+	if len(fields) > 1 {
+		m.Version = fields[1]
+	}
+	return m
+}
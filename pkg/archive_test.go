@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoobjSections(t *testing.T) {
+	tests := []struct {
+		version string
+		kind    byte
+		want    string
+	}{
+		{"go1.23.4", 1, ".text"},
+		{"go1.23.4", 2, ".rodata"},
+		{"go1.23.4", 4, ".data"},
+		{"go1.24.0", 2, ".text"},
+		{"go1.24.0", 4, ".rodata"},
+		{"go1.24.0", 6, ".noptrdata"},
+		{"go1.25.1", 8, ".data"},
+		{"", 3, ".rodata"},
+		{"go1.24.0", 9, ""},
+	}
+	for _, tt := range tests {
+		if got := goobjSections(tt.version)[tt.kind]; got != tt.want {
+			t.Errorf("goobjSections(%q)[%d] = %q, want %q", tt.version, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestReadGoArchiveErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"truncated.a", arMagic + "__.PKGDEF       0           0     0     644     1000      `\n"},
+		{"badsize.a", arMagic + "__.PKGDEF       0           0     0     644     x         `\n"},
+		{"negative.a", arMagic + "__.PKGDEF       0           0     0     644     -20       `\n" + strings.Repeat("x", 40)},
+		{"empty.a", arMagic},
+		{"object.o", goObjectMagic + "linux amd64 go1.24.0 X:none\n" + goobjMagic},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if !isGoArchive(path) {
+			t.Errorf("%s is not recognized as a Go archive", tt.name)
+		}
+		if _, err := readGoArchive(path); err == nil {
+			t.Errorf("readGoArchive(%s) did not fail", tt.name)
+		}
+	}
+	if isGoArchive(filepath.Join(dir, "missing.a")) {
+		t.Error("missing file is recognized as a Go archive")
+	}
+}
+
+const archiveLib = `package lib
+
+import "strings"
+
+var Table = [256]int{1, 2, 3}
+
+func Shout(s string) string { return strings.ToUpper(s) + "!" }
+`
+
+func TestAnalyzeArchive(t *testing.T) {
+	archive := buildTestBinary(t, map[string]string{"lib/lib.go": archiveLib}, nil, "./lib")
+
+	report, err := (&GoWeight{}).AnalyzeBinary(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Binary.Format != FormatArchive || report.Source != SourceObject {
+		t.Errorf("format = %q, source = %q", report.Binary.Format, report.Source)
+	}
+	if report.Build.Path != "example.com/app/lib" || !strings.HasPrefix(report.Build.GoVersion, "go1.") || report.Build.GOOS == "" {
+		t.Errorf("build = %+v", report.Build)
+	}
+	if report.Totals.ExportData == 0 {
+		t.Error("no export data")
+	}
+
+	var lib *PackageReport
+	for _, p := range report.Packages() {
+		if p.Path == "example.com/app/lib" {
+			lib = p
+		}
+	}
+	if lib == nil {
+		t.Fatal("package example.com/app/lib not found")
+	}
+	symbols := make(map[string]*Symbol)
+	for _, sym := range lib.Symbols {
+		symbols[sym.Name] = sym
+	}
+	if s := symbols["example.com/app/lib.Shout"]; s == nil || s.Kind != KindFunc || s.Size == 0 {
+		t.Errorf("Shout = %+v", s)
+	}
+	if s := symbols["example.com/app/lib.Table"]; s == nil || s.Size != 256*8 {
+		t.Errorf("Table = %+v", s)
+	}
+	// 类型描述符等可能归属到其他包，但大部分字节属于被分析的包
+	if report.Totals.Attributed < lib.Size || lib.Size < report.Totals.Attributed/2 {
+		t.Errorf("attributed %d bytes, package has %d", report.Totals.Attributed, lib.Size)
+	}
+}
+
+func TestArchiveModule(t *testing.T) {
+	tests := []struct {
+		pkgPath string
+		want    string // 模块路径，空表示 nil
+	}{
+		{"", ""},
+		{"fmt", ""},
+		{"-toolexec=sh", "-toolexec=sh"},
+		{"example.com/a b", "example.com/a b"},
+		{"example.com/notinbuild/lib", "example.com/notinbuild/lib"},
+	}
+	for _, tt := range tests {
+		var got string
+		if m := archiveModule(tt.pkgPath); m != nil {
+			got = m.Path
+		}
+		if got != tt.want {
+			t.Errorf("archiveModule(%q) = %q, want %q", tt.pkgPath, got, tt.want)
+		}
+	}
+}
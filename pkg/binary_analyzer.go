@@ -1,13 +1,13 @@
 package pkg
 
 import (
-	"debug/buildinfo"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"sort"
+	"slices"
 
 	"github.com/dustin/go-humanize"
 )
@@ -16,101 +16,70 @@ var binaryModuleRegex = regexp.MustCompile(`^\s+(dep|mod)\s+([^\s]+)\s+([^\s]+)`
 
 // BuildAndAnalyzeBinary 构建项目并分析生成的二进制文件
 func (g *GoWeight) BuildAndAnalyzeBinary() []*ModuleEntry {
-	// 修改构建命令以生成二进制文件
-	binaryBuildCmd := []string{"go", "build", "-o", "goweight-temp-binary"}
-	
-	// 如果原始命令中有额外参数，也添加到新命令中
-	originalCmd := g.BuildCmd
-	for i, arg := range originalCmd {
-		if arg == "-o" && i+1 < len(originalCmd) {
-			// 替换输出文件名为临时文件名
-			binaryBuildCmd = append(binaryBuildCmd, "-o", "goweight-temp-binary")
-			i++ // 跳过下一个参数（原输出文件名）
-		} else if arg != "go" && arg != "build" {
-			// 添加其他参数（如 -tags 等）
-			if arg != "-work" && arg != "-a" { // 排除 -work 和 -a 参数
-				binaryBuildCmd = append(binaryBuildCmd, arg)
-			}
-		}
-	}
-
-	// 执行构建命令
-	out, err := exec.Command(binaryBuildCmd[0], binaryBuildCmd[1:]...).CombinedOutput()
-	if err != nil {
-		log.Fatalf("Error building binary: %v\nOutput: %s", err, out)
+	if err := g.BuildBinary("goweight-temp-binary"); err != nil {
+		log.Fatalf("Error building binary: %v", err)
 	}
 
 	// 分析生成的二进制文件
-	defer os.Remove("goweight-temp-binary") // 清理临时文件
+	defer RemoveBinary("goweight-temp-binary") // 清理临时文件
 	return g.ProcessBinary("goweight-temp-binary")
 }
 
-func (g *GoWeight) ProcessBinary(binaryPath string) []*ModuleEntry {
-	// 首先使用 buildinfo 获取模块依赖信息
-	info, err := buildinfo.ReadFile(binaryPath)
-	if err != nil {
-		log.Fatalf("Error reading build info from binary %s: %v", binaryPath, err)
+// BuildAndAnalyze 构建项目并返回生成的二进制文件的完整分析报告
+func (g *GoWeight) BuildAndAnalyze() (*Report, error) {
+	if err := g.BuildBinary("goweight-temp-binary"); err != nil {
+		return nil, err
 	}
+	defer RemoveBinary("goweight-temp-binary") // 清理临时文件
+	return g.AnalyzeBinary("goweight-temp-binary")
+}
 
-	// 然后分析二进制文件的符号表来估算各包的大小
-	pkgSizes, err := analyzeBinarySymbolTable(binaryPath)
-	if err != nil {
-		log.Printf("Warning: Could not analyze symbol table: %v", err)
-		// 如果无法分析符号表，则尝试从模块缓存估算大小
+// BuildBinary 按 BuildCmd 中的参数构建项目，并将二进制文件输出到 output
+func (g *GoWeight) BuildBinary(output string) error {
+	if slices.Contains(g.BuildArgs(), "-buildmode="+BuildModeArchive) {
+		if err := checkArchiveBuild(g.BuildArgs()); err != nil {
+			return err
+		}
 	}
 
-	var modules []*ModuleEntry
-
-	// 添加主模块信息
-	if info.Main.Path != "" {
-		size := uint64(0)
-		if s, exists := pkgSizes[info.Main.Path]; exists && s > 0 {
-			size = s
-		} else {
-			// 如果符号表分析没有提供大小，尝试从模块缓存获取
-			size = estimateModuleSize(info.Main.Path, info.Main.Version)
-		}
+	// 修改构建命令以生成二进制文件
+	binaryBuildCmd := append([]string{"go", "build", "-o", output}, g.BuildArgs()...)
 
-		mainModule := &ModuleEntry{
-			Path:      info.Main.Path,
-			Name:      info.Main.Path,
-			Version:   info.Main.Version,
-			Size:      size,
-			SizeHuman: humanize.Bytes(size),
-		}
-		if info.Main.Path != "" {
-			modules = append(modules, mainModule)
-		}
+	// 执行构建命令
+	out, err := exec.Command(binaryBuildCmd[0], binaryBuildCmd[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\nOutput: %s", err, out)
 	}
+	return nil
+}
 
-	// 添加依赖模块信息
-	for _, dep := range info.Deps {
-		if dep != nil {
-			size := uint64(0)
-			if s, exists := pkgSizes[dep.Path]; exists && s > 0 {
-				size = s
-			} else {
-				// 如果符号表分析没有提供大小，尝试从模块缓存获取
-				size = estimateModuleSize(dep.Path, dep.Version)
-			}
+// RemoveBinary 删除构建出的二进制文件，以及 c-shared 和 c-archive 模式同时生成的 C 头文件
+func RemoveBinary(output string) {
+	os.Remove(output)
+	os.Remove(output + ".h")
+}
 
-			depModule := &ModuleEntry{
-				Path:      dep.Path,
-				Name:      dep.Path,
-				Version:   dep.Version,
-				Size:      size,
-				SizeHuman: humanize.Bytes(size),
-			}
-			modules = append(modules, depModule)
+// BuildArgs 返回 BuildCmd 中除 go build 本身、-work、-a 和 -o 之外的参数（如 -tags 和包路径）
+func (g *GoWeight) BuildArgs() []string {
+	var args []string
+	originalCmd := g.BuildCmd
+	for i := 0; i < len(originalCmd); i++ {
+		arg := originalCmd[i]
+		if arg == "-o" {
+			i++ // 跳过下一个参数（原输出文件名）
+		} else if arg != "go" && arg != "build" && arg != "-work" && arg != "-a" {
+			args = append(args, arg)
 		}
 	}
+	return args
+}
 
-	// 按大小降序排序
-	sort.Slice(modules, func(i, j int) bool {
-		return modules[i].Size > modules[j].Size
-	})
-
-	return modules
+func (g *GoWeight) ProcessBinary(binaryPath string) []*ModuleEntry {
+	report, err := g.AnalyzeBinary(binaryPath)
+	if err != nil {
+		log.Fatalf("Error analyzing binary %s: %v", binaryPath, err)
+	}
+	return report.ModuleEntries()
 }
 
 // processBinaryModule 处理二进制模块信息
@@ -0,0 +1,235 @@
+package pkg
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CgoPackage 是无法确定所属 Go 包的 C 符号归入的伪包，也是它所在的伪模块
+const CgoPackage = "<cgo>"
+
+// dwarfLangGo 是 DW_AT_language 中 Go 的取值，其他语言的编译单元来自 cgo 和 C 库
+const dwarfLangGo = 0x16
+
+// cgoHashPrefix 匹配 cgo 生成的桩函数名前缀 _cgo_<hash>_，同一个包中的桩函数共享同一个 hash
+// Go 一侧的符号形如 pkg._cgo_<hash>_Cfunc_f，C 一侧的桩函数是 _cgo_<hash>_Cfunc_f
+var cgoHashPrefix = regexp.MustCompile(`^_cgo_[0-9a-f]+_`)
+
+// cgoExport 匹配 //export 函数在 Go 一侧的包装函数 _cgoexp_<hash>_<函数名>，它没有包名前缀，
+// C 一侧 _cgo_export.c 中的同名函数调用它
+var cgoExport = regexp.MustCompile(`^_cgoexp_([0-9a-f]+)_(.+)$`)
+
+// compUnit 是一个 DWARF 编译单元：Go 编译单元的名字是包路径，
+// 其他的是 C 翻译单元或汇编文件
+type compUnit struct {
+	name    string
+	compDir string
+	golang  bool
+	ranges  [][2]uint64
+	vars    map[uint64]bool // C 全局和静态变量的地址
+}
+
+// unit 返回翻译单元的路径，相对路径拼上编译目录
+func (u *compUnit) unit() string {
+	if path.IsAbs(u.name) || u.compDir == "" {
+		return u.name
+	}
+	return path.Join(u.compDir, u.name)
+}
+
+func (u *compUnit) contains(sym Symbol) bool {
+	if u.vars[sym.Address] {
+		return true
+	}
+	for _, r := range u.ranges {
+		if sym.Address >= r[0] && sym.Address < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// cgoIndex 记录 cgo 生成的符号属于哪个 Go 包
+type cgoIndex struct {
+	hashes  map[string]string // _cgo_<hash>_ -> 包
+	exports map[string]string // //export 导出的 C 函数名 -> 包
+	owners  map[string]bool   // 包含 cgo 代码的包
+}
+
+// newCgoIndex 从 Go 符号中的 pkg._cgo_<hash>_* 和已经归属的 _cgoexp_ 包装函数找出每个包的 hash
+func newCgoIndex(goSymbols []Symbol) *cgoIndex {
+	x := &cgoIndex{hashes: make(map[string]string), exports: make(map[string]string), owners: make(map[string]bool)}
+	for _, sym := range goSymbols {
+		if sym.Package != "" && cgoExport.MatchString(sym.Name) {
+			x.addExport(sym.Name, sym.Package)
+			continue
+		}
+		pkg, rest, ok := strings.Cut(sym.Name, "._cgo_")
+		if !ok || pkg != sym.Package {
+			continue
+		}
+		if prefix := cgoHashPrefix.FindString("_cgo_" + rest); prefix != "" {
+			x.hashes[prefix] = pkg
+			x.owners[pkg] = true
+		}
+	}
+	return x
+}
+
+// addExport 记录属于 pkg 的 _cgoexp_ 包装函数，同一个包的 _cgo_ 桩函数和导出的 C 函数也因此归属于它
+func (x *cgoIndex) addExport(name, pkg string) {
+	m := cgoExport.FindStringSubmatch(name)
+	if m == nil {
+		return
+	}
+	x.hashes["_cgo_"+m[1]+"_"] = pkg
+	x.exports[m[2]] = pkg
+	x.owners[pkg] = true
+}
+
+// owner 返回 cgo 生成的 C 符号所属的包，不是 cgo 生成的符号返回空字符串
+func (x *cgoIndex) owner(name string) string {
+	if pkg := x.exports[name]; pkg != "" {
+		return pkg
+	}
+	return x.hashes[cgoHashPrefix.FindString(name)]
+}
+
+// cgoSymbols 把不属于任何 Go 包的符号 (foreign) 归属到它们所在的 DWARF 编译单元：
+// Go 编译单元中的是 Go 汇编函数和 //export 包装函数，归入编译单元对应的包；
+// C 翻译单元中的归入拥有这段 cgo 代码的 Go 包，不能确定时归入 <cgo>
+// goSymbols 用来找出 cgo 桩函数的 hash 属于哪个包
+func cgoSymbols(foreign, goSymbols []Symbol, d *dwarf.Data, order binary.ByteOrder) []Symbol {
+	if len(foreign) == 0 {
+		return nil
+	}
+	index := newCgoIndex(goSymbols)
+
+	// 没有 DWARF 时分不清 C 函数和 Go 汇编函数，只归属 cgo 桩函数
+	if d == nil {
+		var symbols []Symbol
+		for _, sym := range foreign {
+			if pkg := index.owner(sym.Name); pkg != "" {
+				sym.Package = pkg
+				symbols = append(symbols, sym)
+			}
+		}
+		return symbols
+	}
+
+	units := readCompUnits(d, order)
+	members := make(map[*compUnit][]int)
+	for i, sym := range foreign {
+		for _, u := range units {
+			if u.contains(sym) {
+				members[u] = append(members[u], i)
+				break
+			}
+		}
+	}
+
+	// 先处理 Go 编译单元，其中的 _cgoexp_ 包装函数决定了 C 一侧导出函数的归属
+	var symbols []Symbol
+	for _, u := range units {
+		if !u.golang {
+			continue
+		}
+		for _, i := range members[u] {
+			sym := foreign[i]
+			sym.Package = u.name
+			index.addExport(sym.Name, u.name)
+			symbols = append(symbols, sym)
+		}
+	}
+	for _, u := range units {
+		if u.golang {
+			continue
+		}
+		owner := ""
+		for _, i := range members[u] {
+			if owner = index.owner(foreign[i].Name); owner != "" {
+				break
+			}
+		}
+		if owner == "" {
+			owner = cgoUnitOwner(u, index.owners)
+		}
+		for _, i := range members[u] {
+			sym := foreign[i]
+			sym.Package = owner
+			sym.Unit = u.unit()
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols
+}
+
+// cgoUnitOwner 根据编译目录判断翻译单元属于哪个包：
+// 标准库中的 C 文件编译目录是 GOROOT/src/<包>（如 runtime/cgo）；
+// 包目录中的 .c 文件取路径末尾与包路径最长重合的 cgo 包（模块缓存中的目录去掉 @版本）
+func cgoUnitOwner(u *compUnit, owners map[string]bool) string {
+	dir := path.Dir(u.unit())
+	if _, std, ok := strings.Cut(dir, "GOROOT/src/"); ok {
+		return std
+	}
+
+	var elems []string
+	for _, e := range strings.Split(dir, "/") {
+		e, _, _ = strings.Cut(e, "@")
+		elems = append(elems, e)
+	}
+	best, bestLen := CgoPackage, 0
+	for pkg := range owners {
+		parts := strings.Split(pkg, "/")
+		n := 0
+		for n < len(parts) && n < len(elems) && parts[len(parts)-1-n] == elems[len(elems)-1-n] {
+			n++
+		}
+		// 至少要求包路径的最后一段完全对上；main 包没有导入路径可比
+		if n > bestLen || (n == bestLen && n > 0 && pkg < best) {
+			best, bestLen = pkg, n
+		}
+	}
+	return best
+}
+
+// readCompUnits 读出 DWARF 中所有编译单元的地址范围，以及 C 编译单元中的变量地址
+func readCompUnits(d *dwarf.Data, order binary.ByteOrder) []*compUnit {
+	var units []*compUnit
+	var current *compUnit
+	r := d.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		if entry.Tag == dwarf.TagCompileUnit {
+			current = &compUnit{vars: make(map[uint64]bool)}
+			current.name, _ = entry.Val(dwarf.AttrName).(string)
+			current.compDir, _ = entry.Val(dwarf.AttrCompDir).(string)
+			current.ranges, _ = d.Ranges(entry)
+			units = append(units, current)
+			if lang, _ := entry.Val(dwarf.AttrLanguage).(int64); lang == dwarfLangGo {
+				// Go 的数据符号都带有包名，不需要读变量
+				current.golang = true
+				r.SkipChildren()
+			}
+			continue
+		}
+		if current == nil || entry.Tag != dwarf.TagVariable {
+			continue
+		}
+		// DW_OP_addr <地址>
+		loc, _ := entry.Val(dwarf.AttrLocation).([]byte)
+		switch {
+		case len(loc) == 9 && loc[0] == 0x03:
+			current.vars[order.Uint64(loc[1:])] = true
+		case len(loc) == 5 && loc[0] == 0x03:
+			current.vars[uint64(order.Uint32(loc[1:]))] = true
+		}
+	}
+	return units
+}
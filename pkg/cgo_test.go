@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCgoIndex(t *testing.T) {
+	index := newCgoIndex([]Symbol{
+		{Name: "example.com/app/clib._cgo_0123abcd_Cfunc_add", Package: "example.com/app/clib"},
+		{Name: "_cgoexp_4567ef_Exported", Package: "example.com/app/exp"},
+		{Name: "fmt.Println", Package: "fmt"},
+	})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"_cgo_0123abcd_Cfunc_add", "example.com/app/clib"},
+		{"_cgo_4567ef_Cfunc_other", "example.com/app/exp"},
+		{"Exported", "example.com/app/exp"},
+		{"_cgo_99_Cfunc_add", ""},
+		{"malloc", ""},
+	}
+	for _, tt := range tests {
+		if got := index.owner(tt.name); got != tt.want {
+			t.Errorf("owner(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if !index.owners["example.com/app/clib"] || !index.owners["example.com/app/exp"] || index.owners["fmt"] {
+		t.Errorf("owners = %v", index.owners)
+	}
+}
+
+func TestCgoUnitOwner(t *testing.T) {
+	owners := map[string]bool{
+		"example.com/app/clib":             true,
+		"github.com/mattn/go-sqlite3":      true,
+		"github.com/other/sqlite3/wrapper": true,
+	}
+	tests := []struct {
+		name, compDir string
+		want          string
+	}{
+		{"gcc_linux_amd64.c", "/usr/lib/go/GOROOT/src/runtime/cgo", "runtime/cgo"},
+		{"/src/app/clib/add.c", "", "example.com/app/clib"},
+		{"sqlite3-binding.c", "/root/go/pkg/mod/github.com/mattn/go-sqlite3@v1.14.22", "github.com/mattn/go-sqlite3"},
+		{"/usr/src/zlib/deflate.c", "", CgoPackage},
+	}
+	for _, tt := range tests {
+		u := &compUnit{name: tt.name, compDir: tt.compDir}
+		if got := cgoUnitOwner(u, owners); got != tt.want {
+			t.Errorf("cgoUnitOwner(%q, %q) = %q, want %q", tt.name, tt.compDir, got, tt.want)
+		}
+	}
+}
+
+const cgoMain = `package main
+
+import (
+	"fmt"
+
+	"example.com/app/clib"
+)
+
+func main() { fmt.Println(clib.Add(1, 2)) }
+`
+
+const cgoLib = `package clib
+
+/*
+static int table[1024] = {1};
+
+int add(int a, int b) { return a + b + table[a & 1023]; }
+*/
+import "C"
+
+func Add(a, b int) int { return int(C.add(C.int(a), C.int(b))) }
+`
+
+func TestCgoAttribution(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not found")
+	}
+	binary := buildTestBinary(t, map[string]string{
+		"main.go":      cgoMain,
+		"clib/clib.go": cgoLib,
+	}, []string{"CGO_ENABLED=1"})
+
+	report, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Binary.Format == "ELF" && !slices.ContainsFunc(report.Binary.Libraries, func(l string) bool { return strings.HasPrefix(l, "libc.") }) {
+		t.Errorf("libraries = %v", report.Binary.Libraries)
+	}
+
+	packages := make(map[string]*PackageReport)
+	for _, p := range report.Packages() {
+		packages[p.Path] = p
+	}
+	clib := packages["example.com/app/clib"]
+	if clib == nil {
+		t.Fatal("package example.com/app/clib not found")
+	}
+	var add, table bool
+	for _, sym := range clib.Symbols {
+		switch sym.Name {
+		case "add":
+			add = sym.Unit != ""
+		case "table":
+			table = sym.Size == 4096
+		}
+	}
+	if !add || !table {
+		t.Errorf("C symbols of clib: add with unit %v, table of 4096 bytes %v", add, table)
+	}
+	if p := packages["runtime/cgo"]; p == nil || p.Size == 0 {
+		t.Error("runtime/cgo has no C code")
+	}
+	// C 符号的 GCC 后缀和符号版本不能被当作包名
+	std, err := LoadStdPackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path := range packages {
+		if !strings.Contains(path, "/") && !strings.Contains(path, ".") && path != "go" && path != CgoPackage && !std[path] {
+			t.Errorf("C symbol attributed to package %q", path)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// debugLink 是剥离了调试信息的 ELF 文件指向独立调试文件的线索
+type debugLink struct {
+	buildID string // .note.gnu.build-id 中的 GNU build ID（十六进制）
+	name    string // .gnu_debuglink 中的文件名
+	crc     uint32 // .gnu_debuglink 中调试文件内容的 CRC32
+}
+
+// readDebugLink 读出 ELF 文件的 GNU build ID 和 .gnu_debuglink
+func readDebugLink(f *elf.File) debugLink {
+	var link debugLink
+	if sec := f.Section(".note.gnu.build-id"); sec != nil {
+		if data, err := sec.Data(); err == nil {
+			link.buildID = gnuBuildID(data, f)
+		}
+	}
+	if sec := f.Section(".gnu_debuglink"); sec != nil {
+		// 以 NUL 结尾的文件名，补齐到 4 字节，后面是 CRC32
+		if data, err := sec.Data(); err == nil {
+			if i := bytes.IndexByte(data, 0); i > 0 {
+				off := (i + 4) &^ 3
+				if off+4 <= len(data) {
+					link.name = string(data[:i])
+					link.crc = f.ByteOrder.Uint32(data[off:])
+				}
+			}
+		}
+	}
+	return link
+}
+
+// gnuBuildID 在 note 节中找出类型为 NT_GNU_BUILD_ID、名字为 GNU 的条目
+func gnuBuildID(data []byte, f *elf.File) string {
+	const ntGNUBuildID = 3
+	for len(data) >= 12 {
+		namesz := f.ByteOrder.Uint32(data)
+		descsz := f.ByteOrder.Uint32(data[4:])
+		typ := f.ByteOrder.Uint32(data[8:])
+		nameEnd := 12 + (uint64(namesz)+3)&^3
+		descEnd := nameEnd + (uint64(descsz)+3)&^3
+		if descEnd > uint64(len(data)) {
+			break
+		}
+		if typ == ntGNUBuildID && string(data[12:12+namesz]) == "GNU\x00" {
+			return hex.EncodeToString(data[nameEnd : nameEnd+uint64(descsz)])
+		}
+		data = data[descEnd:]
+	}
+	return ""
+}
+
+// findDebugFile 为剥离了调试信息的 ELF 二进制文件找到独立的调试文件（objcopy --only-keep-debug 的输出）
+//
+// 指定了 debugFile 时只检查它是否与二进制文件匹配；否则按 GDB 的约定在 debugDirs 中查找：
+// <dir>/.build-id/ab/cdef....debug，以及 .gnu_debuglink 记录的文件名在二进制文件所在目录、
+// 其下的 .debug 目录和 <dir>/<二进制文件所在目录> 中的位置
+func findDebugFile(binaryPath, debugFile string, debugDirs []string) (string, error) {
+	f, err := elf.Open(binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("split debug info needs an ELF binary: %w", err)
+	}
+	link := readDebugLink(f)
+	f.Close()
+
+	if debugFile != "" {
+		if err := link.match(debugFile, link.crc != 0); err != nil {
+			return "", err
+		}
+		return debugFile, nil
+	}
+
+	var candidates []string
+	if len(link.buildID) > 2 {
+		for _, dir := range debugDirs {
+			candidates = append(candidates, filepath.Join(dir, ".build-id", link.buildID[:2], link.buildID[2:]+".debug"))
+		}
+	}
+	if link.name != "" {
+		binDir, _ := filepath.Abs(filepath.Dir(binaryPath))
+		candidates = append(candidates, filepath.Join(binDir, link.name), filepath.Join(binDir, ".debug", link.name))
+		for _, dir := range debugDirs {
+			candidates = append(candidates, filepath.Join(dir, link.name), filepath.Join(dir, binDir, link.name))
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%s has neither a GNU build ID nor a .gnu_debuglink", binaryPath)
+	}
+	for _, c := range candidates {
+		if filepath.Clean(c) == filepath.Clean(binaryPath) {
+			continue
+		}
+		// 按文件名找到的候选必须通过 CRC 校验，按 build ID 找到的只比较 build ID
+		if _, err := os.Stat(c); err == nil && link.match(c, filepath.Base(c) == link.name) == nil {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("no debug file for %s found in %v (build ID %q, debuglink %q)", binaryPath, debugDirs, link.buildID, link.name)
+}
+
+// match 检查调试文件是否属于这个二进制文件：两边都有 build ID 时必须相同，checkCRC 时校验整个文件的 CRC32
+func (link debugLink) match(path string, checkCRC bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ef, err := elf.NewFile(f)
+	if err != nil {
+		return fmt.Errorf("debug file %s: %w", path, err)
+	}
+	if id := readDebugLink(ef).buildID; link.buildID != "" && id != "" && id != link.buildID {
+		return fmt.Errorf("debug file %s has build ID %s, binary has %s", path, id, link.buildID)
+	}
+	if checkCRC {
+		h := crc32.NewIEEE()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		if h.Sum32() != link.crc {
+			return fmt.Errorf("debug file %s does not match the CRC in .gnu_debuglink", path)
+		}
+	}
+	return nil
+}
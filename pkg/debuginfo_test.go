@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// note 编码一个 ELF note 条目，名字和内容都补齐到 4 字节
+func note(name string, typ uint32, desc []byte) []byte {
+	pad := func(b []byte) []byte { return append(b, make([]byte, (4-len(b)%4)%4)...) }
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(name)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(desc)))
+	b = binary.LittleEndian.AppendUint32(b, typ)
+	return append(append(b, pad([]byte(name))...), pad(desc)...)
+}
+
+func TestGNUBuildID(t *testing.T) {
+	f := &elf.File{FileHeader: elf.FileHeader{ByteOrder: binary.LittleEndian}}
+	id := []byte{0xab, 0xcd, 0xef, 0x01, 0x23}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"build id", note("GNU\x00", 3, id), "abcdef0123"},
+		{"after another note", append(note("Go\x00\x00", 4, []byte("go-build-id")), note("GNU\x00", 3, id)...), "abcdef0123"},
+		{"other owner", note("XYZ\x00", 3, id), ""},
+		{"other type", note("GNU\x00", 1, id), ""},
+		{"truncated", note("GNU\x00", 3, id)[:18], ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := gnuBuildID(tt.data, f); got != tt.want {
+			t.Errorf("%s: gnuBuildID = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// splitDebugInfo 用 objcopy 把 binary 的调试信息拆到 binary.debug，返回调试文件路径
+func splitDebugInfo(t *testing.T, binary string) string {
+	t.Helper()
+	if _, err := exec.LookPath("objcopy"); err != nil {
+		t.Skip("objcopy not found")
+	}
+	debug := binary + ".debug"
+	for _, args := range [][]string{
+		{"--only-keep-debug", binary, debug},
+		{"--strip-debug", "--strip-unneeded", "--add-gnu-debuglink=" + debug, binary},
+	} {
+		if out, err := exec.Command("objcopy", args...).CombinedOutput(); err != nil {
+			t.Fatalf("objcopy %v: %v\n%s", args, err, out)
+		}
+	}
+	return debug
+}
+
+func TestFindDebugFile(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{"main.go": helloMain},
+		[]string{"GOOS=linux"}, "-ldflags=-B 0x0123456789abcdef")
+	debug := splitDebugInfo(t, binary)
+
+	// debuglink 指向二进制文件旁边的文件
+	if got, err := findDebugFile(binary, "", nil); err != nil || got != debug {
+		t.Errorf("findDebugFile by debuglink = %q, %v", got, err)
+	}
+
+	// 按 build ID 在调试目录中查找
+	dir := t.TempDir()
+	byID := filepath.Join(dir, ".build-id", "01", "23456789abcdef.debug")
+	if err := os.MkdirAll(filepath.Dir(byID), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(debug, byID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := findDebugFile(binary, "", []string{dir}); err != nil || got != byID {
+		t.Errorf("findDebugFile by build ID = %q, %v", got, err)
+	}
+	if _, err := findDebugFile(binary, "", []string{t.TempDir()}); err == nil {
+		t.Error("findDebugFile without a debug file did not fail")
+	}
+
+	// 指定的调试文件要和二进制文件匹配
+	if got, err := findDebugFile(binary, byID, nil); err != nil || got != byID {
+		t.Errorf("findDebugFile with --debug-file = %q, %v", got, err)
+	}
+	other := buildTestBinary(t, map[string]string{"main.go": helloMain},
+		[]string{"GOOS=linux"}, "-ldflags=-B 0xfedcba9876543210")
+	if _, err := findDebugFile(binary, other, nil); err == nil || !strings.Contains(err.Error(), "build ID") {
+		t.Errorf("findDebugFile with a foreign debug file: %v", err)
+	}
+
+	report, err := (&GoWeight{DebugFile: byID}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Binary.DebugFile != byID || report.Source != SourceSymtab {
+		t.Errorf("debug file = %q, source = %q", report.Binary.DebugFile, report.Source)
+	}
+	stripped, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Totals.Attributed <= stripped.Totals.Attributed {
+		t.Errorf("attributed %d bytes with the debug file, %d without", report.Totals.Attributed, stripped.Totals.Attributed)
+	}
+}
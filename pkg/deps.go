@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"sort"
+
+	"github.com/dustin/go-humanize"
+)
+
+// DependencyWeight 是一个直接依赖连同它带进来的传递依赖的大小
+type DependencyWeight struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	// Size 是模块自身链接的字节数
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+	// Attributable 是去掉这个依赖后不再被链接的所有包（含标准库包）的字节数
+	Attributable      uint64 `json:"attributable"`
+	AttributableHuman string `json:"attributable_human"`
+	// Exclusive 是只通过这个依赖才被链接的模块
+	Exclusive []*LinkedModule `json:"exclusive,omitempty"`
+	// Shared 是这个依赖也用到、但主模块通过其他路径同样需要的模块
+	Shared []*LinkedModule `json:"shared,omitempty"`
+}
+
+// AttributeDirectDependencies 把传递依赖的大小归到引入它们的直接依赖上
+// requirements 是主模块 go.mod 中的 require，为空时把主模块的包直接导入的模块视为直接依赖
+func (ig *ImportGraph) AttributeDirectDependencies(report *Report, requirements []Requirement) []*DependencyWeight {
+	mainModule := report.Build.MainModule.Path
+	modules := make(map[string]*ModuleReport)
+	for _, m := range report.Modules {
+		modules[m.Path] = m
+	}
+
+	var roots []string
+	for path, node := range ig.Nodes {
+		if node.Module == mainModule {
+			roots = append(roots, path)
+		}
+	}
+	sort.Strings(roots)
+
+	direct := make(map[string]bool)
+	if requirements != nil {
+		for _, r := range requirements {
+			if !r.Indirect {
+				direct[r.Path] = true
+			}
+		}
+	} else {
+		for _, root := range roots {
+			for _, imp := range ig.Nodes[root].Imports {
+				if m := ig.Nodes[imp].Module; m != "" && m != mainModule {
+					direct[m] = true
+				}
+			}
+		}
+	}
+
+	var result []*DependencyWeight
+	for path := range direct {
+		dep := &DependencyWeight{Path: path}
+		if m, ok := modules[path]; ok {
+			dep.Version, dep.Size = m.Version, m.Size
+		}
+
+		// 从依赖自身的包出发能到达的包
+		var depPackages []string
+		for p, node := range ig.Nodes {
+			if node.Module == path {
+				depPackages = append(depPackages, p)
+			}
+		}
+		viaDep := ig.reachable(depPackages, nil)
+		// 去掉依赖的包以后，从主模块仍能到达的包
+		without := ig.reachable(roots, func(n *PackageNode) bool { return n.Module == path })
+
+		stillLinked := make(map[string]bool)
+		for p := range without {
+			stillLinked[ig.Nodes[p].Module] = true
+		}
+		seen := make(map[string]bool)
+		for p := range viaDep {
+			node := ig.Nodes[p]
+			if !without[p] {
+				dep.Attributable += node.Size
+			}
+			m := node.Module
+			if m == "" || m == path || m == mainModule || seen[m] || modules[m] == nil {
+				continue
+			}
+			seen[m] = true
+			if stillLinked[m] {
+				dep.Shared = append(dep.Shared, newLinkedModule(modules[m]))
+			} else {
+				dep.Exclusive = append(dep.Exclusive, newLinkedModule(modules[m]))
+			}
+		}
+
+		dep.SizeHuman = humanize.Bytes(dep.Size)
+		dep.AttributableHuman = humanize.Bytes(dep.Attributable)
+		sortLinkedModules(dep.Exclusive)
+		sortLinkedModules(dep.Shared)
+		result = append(result, dep)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Attributable != result[j].Attributable {
+			return result[i].Attributable > result[j].Attributable
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// reachable 返回从 from 出发沿导入边能到达的包（含 from），skip 为真的包不会被访问
+func (ig *ImportGraph) reachable(from []string, skip func(*PackageNode) bool) map[string]bool {
+	seen := make(map[string]bool)
+	stack := append([]string(nil), from...)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node, ok := ig.Nodes[n]
+		if seen[n] || !ok || (skip != nil && skip(node)) {
+			continue
+		}
+		seen[n] = true
+		stack = append(stack, node.Imports...)
+	}
+	return seen
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+// depsTestReport 构建 app -> (a, c)、a -> (x, shared)、c -> shared、x -> strings 的报告和导入图
+func depsTestReport() (*Report, *ImportGraph) {
+	report := &Report{
+		Build: BuildMetadata{MainModule: ModuleVersion{Path: "example.com/app"}},
+		Modules: []*ModuleReport{
+			{Path: "example.com/app", Main: true, Size: 10},
+			{Path: "github.com/a/a", Version: "v1.0.0", Size: 100},
+			{Path: "github.com/c/c", Version: "v1.0.0", Size: 200},
+			{Path: "github.com/x/x", Size: 50},
+			{Path: "github.com/s/shared", Size: 30},
+			{Path: StdModule, Size: 1000},
+		},
+	}
+	graph := &ImportGraph{
+		Roots: []string{"example.com/app"},
+		Nodes: map[string]*PackageNode{
+			"example.com/app":     {Module: "example.com/app", Size: 10, Imports: []string{"github.com/a/a", "github.com/c/c"}},
+			"github.com/a/a":      {Module: "github.com/a/a", Size: 100, Imports: []string{"github.com/x/x", "github.com/s/shared"}},
+			"github.com/c/c":      {Module: "github.com/c/c", Size: 200, Imports: []string{"github.com/s/shared"}},
+			"github.com/x/x":      {Module: "github.com/x/x", Size: 50, Imports: []string{"strings"}},
+			"github.com/s/shared": {Module: "github.com/s/shared", Size: 30},
+			"strings":             {Standard: true, Size: 7},
+		},
+	}
+	return report, graph
+}
+
+func TestAttributeDirectDependencies(t *testing.T) {
+	report, graph := depsTestReport()
+	requirements := []Requirement{
+		{Path: "github.com/a/a"},
+		{Path: "github.com/c/c"},
+		{Path: "github.com/x/x", Indirect: true},
+		{Path: "github.com/s/shared", Indirect: true},
+	}
+
+	type weight struct {
+		attributable uint64
+		exclusive    []string
+		shared       []string
+	}
+	summarize := func(result []*DependencyWeight) map[string]weight {
+		got := make(map[string]weight)
+		for _, d := range result {
+			w := weight{attributable: d.Attributable}
+			for _, m := range d.Exclusive {
+				w.exclusive = append(w.exclusive, m.Path)
+			}
+			for _, m := range d.Shared {
+				w.shared = append(w.shared, m.Path)
+			}
+			got[d.Path] = w
+		}
+		return got
+	}
+
+	want := map[string]weight{
+		// a 独占 x 以及只被 x 导入的 strings，shared 还被 c 需要
+		"github.com/a/a": {attributable: 157, exclusive: []string{"github.com/x/x"}, shared: []string{"github.com/s/shared"}},
+		"github.com/c/c": {attributable: 200, shared: []string{"github.com/s/shared"}},
+	}
+	result := graph.AttributeDirectDependencies(report, requirements)
+	if got := summarize(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("AttributeDirectDependencies = %+v, want %+v", got, want)
+	}
+	if result[0].Path != "github.com/c/c" {
+		t.Errorf("first dependency = %s, want the largest attributable", result[0].Path)
+	}
+
+	// 没有 go.mod 时，主模块直接导入的模块就是直接依赖
+	if got := summarize(graph.AttributeDirectDependencies(report, nil)); !reflect.DeepEqual(got, want) {
+		t.Errorf("without go.mod = %+v, want %+v", got, want)
+	}
+}
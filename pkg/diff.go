@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"sort"
+
+	"github.com/dustin/go-humanize"
+)
+
+// 条目在两次分析之间的变化状态
+const (
+	DiffAdded     = "added"
+	DiffRemoved   = "removed"
+	DiffChanged   = "changed"
+	DiffUnchanged = "unchanged"
+)
+
+// ReportDiff 是两份报告之间的大小差异
+type ReportDiff struct {
+	Old        BinaryInfo   `json:"old"`
+	New        BinaryInfo   `json:"new"`
+	Delta      int64        `json:"delta"`
+	DeltaHuman string       `json:"delta_human"`
+	Modules    []*EntryDiff `json:"modules"`
+	Packages   []*EntryDiff `json:"packages"`
+	Groups     []*EntryDiff `json:"groups,omitempty"`
+}
+
+// EntryDiff 是一个模块或包在两次分析之间的大小变化
+type EntryDiff struct {
+	Name       string `json:"name"`
+	OldVersion string `json:"old_version,omitempty"`
+	NewVersion string `json:"new_version,omitempty"`
+	OldSize    uint64 `json:"old_size"`
+	NewSize    uint64 `json:"new_size"`
+	Delta      int64  `json:"delta"`
+	DeltaHuman string `json:"delta_human"`
+	Status     string `json:"status"`
+}
+
+// DiffReports 比较两份报告，按模块和包列出大小变化，变化最大的排在最前
+func DiffReports(oldReport, newReport *Report) *ReportDiff {
+	diff := &ReportDiff{
+		Old:   oldReport.Binary,
+		New:   newReport.Binary,
+		Delta: int64(newReport.Binary.Size) - int64(oldReport.Binary.Size),
+	}
+	diff.DeltaHuman = humanDelta(diff.Delta)
+
+	oldModules := make(map[string]*ModuleReport)
+	for _, m := range oldReport.Modules {
+		oldModules[m.Path] = m
+	}
+	newModules := make(map[string]*ModuleReport)
+	for _, m := range newReport.Modules {
+		newModules[m.Path] = m
+	}
+	for path := range union(oldModules, newModules) {
+		entry := &EntryDiff{Name: path}
+		if m, ok := oldModules[path]; ok {
+			entry.OldSize, entry.OldVersion = m.Size, m.Version
+		}
+		if m, ok := newModules[path]; ok {
+			entry.NewSize, entry.NewVersion = m.Size, m.Version
+		}
+		_, inOld := oldModules[path]
+		_, inNew := newModules[path]
+		diff.Modules = append(diff.Modules, finishEntry(entry, inOld, inNew))
+	}
+
+	oldPackages := make(map[string]*PackageReport)
+	for _, p := range oldReport.Packages() {
+		oldPackages[p.Path] = p
+	}
+	newPackages := make(map[string]*PackageReport)
+	for _, p := range newReport.Packages() {
+		newPackages[p.Path] = p
+	}
+	for path := range union(oldPackages, newPackages) {
+		entry := &EntryDiff{Name: path}
+		p, inOld := oldPackages[path]
+		if inOld {
+			entry.OldSize = p.Size
+		}
+		p, inNew := newPackages[path]
+		if inNew {
+			entry.NewSize = p.Size
+		}
+		diff.Packages = append(diff.Packages, finishEntry(entry, inOld, inNew))
+	}
+
+	sortEntryDiffs(diff.Modules)
+	sortEntryDiffs(diff.Packages)
+	return diff
+}
+
+// DiffGroups 按 gr 聚合两份报告后比较各分组的大小变化
+func DiffGroups(oldReport, newReport *Report, gr Grouping) []*EntryDiff {
+	oldGroups := make(map[string]*ModuleEntry)
+	for _, e := range oldReport.GroupPackages(gr, nil) {
+		oldGroups[e.Name] = e
+	}
+	newGroups := make(map[string]*ModuleEntry)
+	for _, e := range newReport.GroupPackages(gr, nil) {
+		newGroups[e.Name] = e
+	}
+
+	var entries []*EntryDiff
+	for name := range union(oldGroups, newGroups) {
+		entry := &EntryDiff{Name: name}
+		e, inOld := oldGroups[name]
+		if inOld {
+			entry.OldSize, entry.OldVersion = e.Size, e.Version
+		}
+		e, inNew := newGroups[name]
+		if inNew {
+			entry.NewSize, entry.NewVersion = e.Size, e.Version
+		}
+		entries = append(entries, finishEntry(entry, inOld, inNew))
+	}
+	sortEntryDiffs(entries)
+	return entries
+}
+
+// finishEntry 计算差值和状态
+func finishEntry(entry *EntryDiff, inOld, inNew bool) *EntryDiff {
+	entry.Delta = int64(entry.NewSize) - int64(entry.OldSize)
+	entry.DeltaHuman = humanDelta(entry.Delta)
+	switch {
+	case !inOld:
+		entry.Status = DiffAdded
+	case !inNew:
+		entry.Status = DiffRemoved
+	case entry.Delta != 0 || entry.OldVersion != entry.NewVersion:
+		entry.Status = DiffChanged
+	default:
+		entry.Status = DiffUnchanged
+	}
+	return entry
+}
+
+// sortEntryDiffs 按变化量的绝对值降序排序
+func sortEntryDiffs(entries []*EntryDiff) {
+	abs := func(v int64) int64 {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if abs(entries[i].Delta) != abs(entries[j].Delta) {
+			return abs(entries[i].Delta) > abs(entries[j].Delta)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// humanDelta 把有符号的字节差值格式化为 +1.2 kB / -300 B
+func humanDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + humanize.Bytes(uint64(-delta))
+	}
+	return "+" + humanize.Bytes(uint64(delta))
+}
+
+// union 返回两个映射中键的并集
+func union[V any](a, b map[string]V) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
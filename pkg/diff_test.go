@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func diffTestReports() (*Report, *Report) {
+	oldReport := &Report{
+		Binary: BinaryInfo{Path: "old", Size: 1000},
+		Modules: []*ModuleReport{
+			{Path: "github.com/a/lib", Version: "v1.0.0", Size: 300, Packages: []*PackageReport{{Path: "github.com/a/lib", Size: 300}}},
+			{Path: "github.com/b/gone", Version: "v0.1.0", Size: 100, Packages: []*PackageReport{{Path: "github.com/b/gone", Size: 100}}},
+			{Path: StdModule, Size: 500, Packages: []*PackageReport{{Path: "fmt", Size: 500}}},
+		},
+	}
+	newReport := &Report{
+		Binary: BinaryInfo{Path: "new", Size: 1200},
+		Modules: []*ModuleReport{
+			{Path: "github.com/a/lib", Version: "v1.1.0", Size: 300, Packages: []*PackageReport{{Path: "github.com/a/lib", Size: 300}}},
+			{Path: "github.com/c/new", Version: "v2.0.0", Size: 400, Packages: []*PackageReport{{Path: "github.com/c/new", Size: 400}}},
+			{Path: StdModule, Size: 500, Packages: []*PackageReport{{Path: "fmt", Size: 500}}},
+		},
+	}
+	return oldReport, newReport
+}
+
+func TestDiffReports(t *testing.T) {
+	diff := DiffReports(diffTestReports())
+	if diff.Delta != 200 || diff.DeltaHuman != "+200 B" {
+		t.Errorf("delta = %d %q", diff.Delta, diff.DeltaHuman)
+	}
+
+	tests := []struct {
+		name   string
+		delta  int64
+		status string
+	}{
+		{"github.com/c/new", 400, DiffAdded},
+		{"github.com/b/gone", -100, DiffRemoved},
+		// 大小不变但版本变化也算 changed
+		{"github.com/a/lib", 0, DiffChanged},
+		{StdModule, 0, DiffUnchanged},
+	}
+	if len(diff.Modules) != len(tests) {
+		t.Fatalf("got %d module diffs, want %d", len(diff.Modules), len(tests))
+	}
+	for i, tt := range tests {
+		got := diff.Modules[i]
+		if got.Name != tt.name || got.Delta != tt.delta || got.Status != tt.status {
+			t.Errorf("module diff %d = %s %d %s, want %s %d %s", i, got.Name, got.Delta, got.Status, tt.name, tt.delta, tt.status)
+		}
+	}
+	if diff.Packages[0].Name != "github.com/c/new" || diff.Packages[1].DeltaHuman != "-100 B" {
+		t.Errorf("package diffs are not sorted by absolute delta: %+v %+v", diff.Packages[0], diff.Packages[1])
+	}
+}
+
+func TestDiffGroups(t *testing.T) {
+	rules := &Rules{Groups: []*RuleGroup{{Name: "third party", Patterns: []string{"github.com/..."}}}}
+	if err := rules.compile(); err != nil {
+		t.Fatal(err)
+	}
+	oldReport, newReport := diffTestReports()
+	groups := DiffGroups(oldReport, newReport, Grouping{Mode: GroupByRules, Rules: rules})
+
+	want := map[string]int64{"third party": 300, DefaultOtherGroup: 0}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for _, g := range groups {
+		if delta, ok := want[g.Name]; !ok || g.Delta != delta {
+			t.Errorf("group %s delta = %d, want %d", g.Name, g.Delta, delta)
+		}
+	}
+}
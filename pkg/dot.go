@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+)
+
+// DOTOptions 控制 Graphviz DOT 输出
+type DOTOptions struct {
+	// ClusterByModule 为真时把同一模块的包放进同一个 cluster 子图
+	ClusterByModule bool
+	// MinSize 隐藏链接大小低于该值的包（根包始终显示）
+	MinSize uint64
+}
+
+// WriteDOT 把导入图输出为 Graphviz DOT 格式，节点大小和颜色与链接字节数成正比，边上标注目标包的保留大小
+func WriteDOT(w io.Writer, graph *ImportGraph, opts DOTOptions) error {
+	bw := bufio.NewWriter(w)
+	retained := graph.RetainedSizes()
+
+	isRoot := make(map[string]bool)
+	for _, r := range graph.Roots {
+		isRoot[r] = true
+	}
+
+	// 选出需要显示的节点
+	var visible []*PackageNode
+	shown := make(map[string]bool)
+	maxSize := uint64(1)
+	for _, node := range graph.Nodes {
+		if node.Size < opts.MinSize && !isRoot[node.ImportPath] {
+			continue
+		}
+		visible = append(visible, node)
+		shown[node.ImportPath] = true
+		if node.Size > maxSize {
+			maxSize = node.Size
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].ImportPath < visible[j].ImportPath })
+
+	fmt.Fprintln(bw, "digraph goweight {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=box, style=filled, fontname=\"Helvetica\"];")
+	fmt.Fprintln(bw, "\tedge [fontname=\"Helvetica\", fontsize=9, color=\"#888888\"];")
+
+	writeNode := func(indent string, node *PackageNode) {
+		// 用平方根缩放，避免 runtime 这类大包让其他节点小到看不见
+		ratio := math.Sqrt(float64(node.Size) / float64(maxSize))
+		fmt.Fprintf(bw, "%s%s [label=%s, fontsize=%.1f, width=%.2f, fillcolor=\"0.000 %.3f 1.000\"];\n",
+			indent,
+			strconv.Quote(node.ImportPath),
+			strconv.Quote(node.ImportPath+"\n"+humanize.Bytes(node.Size)),
+			10+20*ratio,
+			0.75+3*ratio,
+			0.05+0.85*ratio)
+	}
+
+	if opts.ClusterByModule {
+		clusters := make(map[string][]*PackageNode)
+		var names []string
+		for _, node := range visible {
+			module := node.Module
+			if module == "" {
+				module = "std"
+			}
+			if _, ok := clusters[module]; !ok {
+				names = append(names, module)
+			}
+			clusters[module] = append(clusters[module], node)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			var total uint64
+			for _, node := range clusters[name] {
+				total += node.Size
+			}
+			fmt.Fprintf(bw, "\tsubgraph cluster_%d {\n", i)
+			fmt.Fprintf(bw, "\t\tlabel=%s;\n", strconv.Quote(name+" ("+humanize.Bytes(total)+")"))
+			fmt.Fprintln(bw, "\t\tstyle=rounded;")
+			for _, node := range clusters[name] {
+				writeNode("\t\t", node)
+			}
+			fmt.Fprintln(bw, "\t}")
+		}
+	} else {
+		for _, node := range visible {
+			writeNode("\t", node)
+		}
+	}
+
+	for _, node := range visible {
+		for _, imp := range node.Imports {
+			if !shown[imp] {
+				continue
+			}
+			fmt.Fprintf(bw, "\t%s -> %s [label=%s];\n",
+				strconv.Quote(node.ImportPath),
+				strconv.Quote(imp),
+				strconv.Quote(humanize.Bytes(retained[imp])))
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    DOTOptions
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "all packages",
+			want: []string{
+				"digraph goweight {",
+				`"example.com/a" -> "example.com/b" [label="1.0 kB"];`,
+				`"example.com/app" -> "example.com/c" [label="20 B"];`,
+			},
+			notWant: []string{"subgraph"},
+		},
+		{
+			name:    "min size hides small packages but keeps roots",
+			opts:    DOTOptions{MinSize: 500},
+			want:    []string{`"example.com/app" [label=`, `"example.com/b" [label=`},
+			notWant: []string{`"example.com/a" [label=`, `-> "example.com/c"`},
+		},
+		{
+			name: "cluster by module",
+			opts: DOTOptions{ClusterByModule: true},
+			want: []string{"subgraph cluster_0 {", `label="std (1.1 kB)";`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteDOT(&buf, testGraph(), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output does not contain %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/dustin/go-humanize"
+)
+
+// majorSuffix 匹配模块路径末尾的主版本后缀：/v2、gopkg.in 的 .v3
+var majorSuffix = regexp.MustCompile(`(/v[0-9]+|\.v[0-9]+)$`)
+
+// DuplicateGroup 是同一个模块被链接了多份：不同的主版本，或通过 replace 共享上游的分支
+type DuplicateGroup struct {
+	Module    string           `json:"module"`
+	Size      uint64           `json:"size"`
+	SizeHuman string           `json:"size_human"`
+	Copies    []*DuplicateCopy `json:"copies"`
+}
+
+// DuplicateCopy 是重复模块的其中一份
+type DuplicateCopy struct {
+	Path      string         `json:"path"`
+	Version   string         `json:"version,omitempty"`
+	Replace   *ModuleVersion `json:"replace,omitempty"`
+	Size      uint64         `json:"size"`
+	SizeHuman string         `json:"size_human"`
+	// ImportChain 是从主包到这份拷贝中某个包的最短导入链
+	ImportChain []string `json:"import_chain,omitempty"`
+}
+
+// unversionedPath 去掉模块路径的主版本后缀
+func unversionedPath(path string) string {
+	return majorSuffix.ReplaceAllString(path, "")
+}
+
+// Duplicates 找出去掉主版本后缀后路径相同、或通过 replace 指向同一上游的模块
+// graph 不为空时给每份拷贝附上导入链
+func (r *Report) Duplicates(graph *ImportGraph) []*DuplicateGroup {
+	// 用并查集把模块路径、replace 路径去掉版本后缀后连在一起
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(x string) string {
+		if parent[x] == "" || parent[x] == x {
+			parent[x] = x
+			return x
+		}
+		parent[x] = find(parent[x])
+		return parent[x]
+	}
+	union := func(a, b string) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	var deps []*ModuleReport
+	for _, m := range r.Modules {
+		if m.Path == StdModule {
+			continue
+		}
+		deps = append(deps, m)
+		union(m.Path, unversionedPath(m.Path))
+		if m.Replace != nil && m.Replace.Path != "" && !isLocalPath(m.Replace.Path) {
+			union(m.Path, m.Replace.Path)
+			union(m.Replace.Path, unversionedPath(m.Replace.Path))
+		}
+	}
+
+	byRoot := make(map[string][]*ModuleReport)
+	for _, m := range deps {
+		root := find(m.Path)
+		byRoot[root] = append(byRoot[root], m)
+	}
+
+	var groups []*DuplicateGroup
+	for _, members := range byRoot {
+		if len(members) < 2 {
+			continue
+		}
+		group := &DuplicateGroup{}
+		for _, m := range members {
+			c := &DuplicateCopy{Path: m.Path, Version: m.Version, Replace: m.Replace, Size: m.Size, SizeHuman: humanize.Bytes(m.Size)}
+			if graph != nil {
+				c.ImportChain = graph.moduleChain(m)
+			}
+			group.Copies = append(group.Copies, c)
+			group.Size += m.Size
+		}
+		sort.Slice(group.Copies, func(i, j int) bool { return group.Copies[i].Path < group.Copies[j].Path })
+		group.Module = unversionedPath(group.Copies[0].Path)
+		group.SizeHuman = humanize.Bytes(group.Size)
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		return groups[i].Module < groups[j].Module
+	})
+	return groups
+}
+
+// isLocalPath 判断 replace 目标是否为本地目录
+func isLocalPath(path string) bool {
+	return len(path) > 0 && (path[0] == '.' || path[0] == '/')
+}
+
+// moduleChain 返回到模块中任意一个包的最短导入链
+func (ig *ImportGraph) moduleChain(m *ModuleReport) []string {
+	var best []string
+	for _, p := range m.Packages {
+		chain := ig.ImportChain(p.Path)
+		if chain != nil && (best == nil || len(chain) < len(best)) {
+			best = chain
+		}
+	}
+	return best
+}
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnversionedPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"github.com/foo/bar/v2", "github.com/foo/bar"},
+		{"github.com/foo/bar", "github.com/foo/bar"},
+		{"gopkg.in/yaml.v3", "gopkg.in/yaml"},
+		{"github.com/foo/v2x", "github.com/foo/v2x"},
+		{"github.com/foo/bar/v10", "github.com/foo/bar"},
+	}
+	for _, tt := range tests {
+		if got := unversionedPath(tt.path); got != tt.want {
+			t.Errorf("unversionedPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	report := &Report{Modules: []*ModuleReport{
+		{Path: "gopkg.in/yaml.v2", Size: 60},
+		{Path: "gopkg.in/yaml.v3", Size: 70},
+		{Path: "github.com/foo/bar", Size: 10},
+		{Path: "github.com/foo/bar/v2", Size: 20, Packages: []*PackageReport{{Path: "github.com/foo/bar/v2"}}},
+		// 替换成分支的模块和分支本身的上游同时被链接
+		{Path: "github.com/up/lib", Size: 5, Replace: &ModuleVersion{Path: "github.com/fork/lib", Version: "v1.0.1"}},
+		{Path: "github.com/fork/lib", Size: 6},
+		// 替换为本地目录的模块不算重复
+		{Path: "github.com/local/one", Size: 1, Replace: &ModuleVersion{Path: "../one"}},
+		{Path: "github.com/local/two", Size: 1, Replace: &ModuleVersion{Path: "../one"}},
+		{Path: "github.com/alone/mod", Size: 100},
+		{Path: StdModule, Size: 1000},
+	}}
+	graph := &ImportGraph{
+		Roots: []string{"example.com/app"},
+		Nodes: map[string]*PackageNode{
+			"example.com/app":       {Imports: []string{"github.com/foo/bar/v2"}},
+			"github.com/foo/bar/v2": {},
+		},
+	}
+
+	type group struct {
+		size   uint64
+		copies []string
+	}
+	want := map[string]group{
+		"gopkg.in/yaml":       {130, []string{"gopkg.in/yaml.v2", "gopkg.in/yaml.v3"}},
+		"github.com/foo/bar":  {30, []string{"github.com/foo/bar", "github.com/foo/bar/v2"}},
+		"github.com/fork/lib": {11, []string{"github.com/fork/lib", "github.com/up/lib"}},
+	}
+	groups := report.Duplicates(graph)
+	got := make(map[string]group)
+	for _, g := range groups {
+		var copies []string
+		for _, c := range g.Copies {
+			copies = append(copies, c.Path)
+		}
+		got[g.Module] = group{g.Size, copies}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Duplicates = %+v, want %+v", got, want)
+	}
+	if groups[0].Module != "gopkg.in/yaml" {
+		t.Errorf("largest group = %s", groups[0].Module)
+	}
+
+	chain := groups[1].Copies[1].ImportChain
+	if !reflect.DeepEqual(chain, []string{"example.com/app", "github.com/foo/bar/v2"}) {
+		t.Errorf("import chain of github.com/foo/bar/v2 = %v", chain)
+	}
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// 构建模式，对应 go build -buildmode
+const (
+	BuildModeExe      = "exe"
+	BuildModePIE      = "pie"
+	BuildModeCShared  = "c-shared"
+	BuildModeCArchive = "c-archive"
+	BuildModePlugin   = "plugin"
+	BuildModeArchive  = "archive"
+)
+
+// BuildModes 是 --buildmode 可以选择的构建模式
+var BuildModes = []string{BuildModeExe, BuildModePIE, BuildModeCShared, BuildModeCArchive, BuildModePlugin, BuildModeArchive}
+
+// checkArchiveBuild 检查 -buildmode=archive 要构建的包：go build 会忽略其中的 main 包，
+// 只有 main 包时只输出 "no packages to build"，这里给出更明确的错误
+func checkArchiveBuild(args []string) error {
+	out, err := exec.Command("go", append([]string{"list", "-f", "{{if eq .Name \"main\"}}{{.ImportPath}}{{end}}"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("go list: %v\n%s", err, out)
+	}
+	if main := strings.Fields(string(out)); len(main) > 0 {
+		return fmt.Errorf("-buildmode=archive cannot build main package %s: analyze a library package instead, or use -buildmode=c-archive", strings.Join(main, ", "))
+	}
+	return nil
+}
+
+// relocationSymbols 把位置无关代码（PIE、c-shared、plugin）的动态重定位表归属到被重定位的符号所在的包
+// 数据中的每个指针都需要一条重定位记录，每个包得到一个 go:rela.<包> 符号，大小是这些记录占用的字节数
+func relocationSymbols(f *elf.File, symbols []Symbol) []Symbol {
+	if f.Type != elf.ET_DYN {
+		return nil
+	}
+	is32 := f.Class == elf.ELFCLASS32
+	entSize := uint64(24) // Elf64_Rela
+	if is32 {
+		entSize = 12 // Elf32_Rela
+	}
+	// 外部链接器生成 .rela.dyn，Go 的内部链接器生成 .rela
+	sec := f.Section(".rela.dyn")
+	if sec == nil {
+		sec = f.Section(".rela")
+	}
+	if sec == nil {
+		// 386 和 arm 使用不带 addend 的 .rel.dyn（内部链接时是 .rel）
+		if sec = f.Section(".rel.dyn"); sec == nil {
+			if sec = f.Section(".rel"); sec == nil {
+				return nil
+			}
+		}
+		entSize = 16
+		if is32 {
+			entSize = 8
+		}
+	}
+	data, err := sec.Data()
+	if err != nil {
+		return nil
+	}
+
+	sorted := make([]*Symbol, 0, len(symbols))
+	for i := range symbols {
+		if symbols[i].Size > 0 {
+			sorted = append(sorted, &symbols[i])
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Address < sorted[j].Address })
+
+	sizes := make(map[string]uint64)
+	for off := uint64(0); off+entSize <= uint64(len(data)); off += entSize {
+		var addr uint64
+		if is32 {
+			addr = uint64(f.ByteOrder.Uint32(data[off:]))
+		} else {
+			addr = f.ByteOrder.Uint64(data[off:])
+		}
+		i := sort.Search(len(sorted), func(i int) bool { return sorted[i].Address > addr }) - 1
+		if i >= 0 && addr < sorted[i].Address+sorted[i].Size {
+			sizes[sorted[i].Package] += entSize
+		}
+	}
+
+	var result []Symbol
+	for pkg, size := range sizes {
+		result = append(result, Symbol{Name: "go:rela." + pkg, Size: size, Package: pkg, Section: sec.Name})
+	}
+	return result
+}
+
+// objectBuildInfo 读取 c-archive 中 go.o 的 .go.buildinfo 节
+// debug/buildinfo 只接受可执行文件，可重定位目标文件需要自己解析：
+// 16 字节的头（魔数、指针大小、标志）之后是两个带长度前缀的字符串，Go 版本和模块信息
+func objectBuildInfo(f *elf.File) *buildinfo.BuildInfo {
+	sec := f.Section(".go.buildinfo")
+	if sec == nil {
+		return nil
+	}
+	data, err := sec.Data()
+	if err != nil || len(data) < 32 || string(data[:14]) != "\xff Go buildinf:" || data[15]&2 == 0 {
+		return nil
+	}
+	data = data[32:]
+	readString := func() string {
+		n, w := binary.Uvarint(data)
+		if w <= 0 || n > uint64(len(data)-w) {
+			data = nil
+			return ""
+		}
+		s := string(data[w : w+int(n)])
+		data = data[w+int(n):]
+		return s
+	}
+	goVersion, mod := readString(), readString()
+	// 模块信息前后各有 16 字节的哨兵
+	if len(mod) < 33 {
+		return &debug.BuildInfo{GoVersion: goVersion}
+	}
+	info, err := debug.ParseBuildInfo(mod[16 : len(mod)-16])
+	if err != nil {
+		return nil
+	}
+	info.GoVersion = goVersion
+	return info
+}
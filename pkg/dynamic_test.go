@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildArchiveOfMainPackage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go list in short mode")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":     "module example.com/app\n\ngo 1.24\n",
+		"main.go":    helloMain,
+		"lib/lib.go": archiveLib,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOWORK", "off")
+
+	tests := []struct {
+		packages string
+		wantErr  string
+	}{
+		{".", "cannot build main package example.com/app"},
+		{"./lib", ""},
+	}
+	for _, tt := range tests {
+		g := &GoWeight{BuildCmd: []string{"go", "build", "-buildmode=" + BuildModeArchive, tt.packages}}
+		output := filepath.Join(dir, "out.a")
+		err := g.BuildBinary(output)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("building %s: %v", tt.packages, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("building %s: error %v, want %q", tt.packages, err, tt.wantErr)
+		}
+		os.Remove(output)
+	}
+}
+
+func TestRelocationSymbols(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{"main.go": helloMain}, nil, "-buildmode=pie")
+
+	report, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Binary.Format != "ELF" {
+		t.Skipf("pie builds %s files on this platform", report.Binary.Format)
+	}
+	var relocs uint64
+	for _, p := range report.Packages() {
+		for _, sym := range p.Symbols {
+			if sym.Kind == KindReloc {
+				if sym.Name != "go:rela."+p.Path {
+					t.Errorf("relocation symbol %s in package %s", sym.Name, p.Path)
+				}
+				relocs += sym.Size
+			}
+		}
+	}
+	if relocs == 0 {
+		t.Error("no relocation symbols in a PIE binary")
+	}
+}
+
+const cArchiveMain = `package main
+
+import "C"
+
+import (
+	"embed"
+	"fmt"
+)
+
+//go:embed static
+var static embed.FS
+
+//export Hello
+func Hello() {
+	b, _ := static.ReadFile("static/a.txt")
+	fmt.Println(len(b))
+}
+
+func main() {}
+`
+
+func TestCArchive(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not found")
+	}
+	archive := buildTestBinary(t, map[string]string{
+		"main.go":      cArchiveMain,
+		"static/a.txt": strings.Repeat("a", 3000),
+	}, []string{"CGO_ENABLED=1"}, "-buildmode=c-archive")
+
+	report, err := (&GoWeight{}).AnalyzeBinary(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Binary.Format != FormatArchive || report.Build.MainModule.Path != "example.com/app" {
+		t.Errorf("format = %q, main module = %q", report.Binary.Format, report.Build.MainModule.Path)
+	}
+	if report.Totals.Embedded != 3000 || len(report.Embeds) != 1 || report.Embeds[0].Files[0].Name != "static/a.txt" {
+		t.Errorf("embedded = %d, embeds = %+v", report.Totals.Embedded, report.Embeds)
+	}
+
+	sizes := make(map[string]uint64)
+	for _, p := range report.Packages() {
+		sizes[p.Path] = p.Size
+	}
+	for _, path := range []string{"example.com/app", "fmt", "runtime", "runtime/cgo"} {
+		if sizes[path] == 0 {
+			t.Errorf("package %s has no bytes", path)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package pkg
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// EmbedReport 是一个包通过 //go:embed 嵌入的文件
+type EmbedReport struct {
+	Package   string          `json:"package"`
+	Size      uint64          `json:"size"`
+	SizeHuman string          `json:"size_human"`
+	Files     []*EmbeddedFile `json:"files"`
+}
+
+// EmbeddedFile 是一个嵌入的文件
+type EmbeddedFile struct {
+	Name string `json:"name"`
+	// Variable 是 embed.FS 变量名，嵌入为 string 或 []byte 的文件为空
+	Variable  string `json:"variable,omitempty"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+}
+
+// maxEmbedFiles 是一个 embed.FS 文件表最多的条目数，更大的长度来自损坏的数据，
+// 限制它也保证 n*entrySize 不会溢出
+const maxEmbedFiles = 1 << 24
+
+// readEmbedFS 从编译器为每个 embed.FS 变量生成的 <包>.<变量>.files 符号中读出文件表
+//
+// 符号内容是一个切片头 (ptr, len, cap)，后面跟 len 个 {name string; data string; hash [16]byte}
+func readEmbedFS(binaryPath, arch string, symbols []Symbol, mainPath string) ([]*EmbedReport, error) {
+	var tables []Symbol
+	for _, sym := range symbols {
+		if strings.HasSuffix(sym.Name, ".files") && sym.Kind == KindData {
+			tables = append(tables, sym)
+		}
+	}
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	img, err := openImageReader(binaryPath, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer img.Close()
+	return readEmbedTables(img, tables, mainPath), nil
+}
+
+// objectEmbeds 读出可重定位目标文件（c-archive 中的 go.o）中的 embed.FS 文件表，
+// 文件表中的指针要先按重定位记录填上
+func objectEmbeds(f *elf.File, mainPath string) ([]*EmbedReport, error) {
+	img, bases, err := relocatedImage(f)
+	if err != nil {
+		return nil, err
+	}
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil, err
+	}
+	var tables []Symbol
+	for _, sym := range syms {
+		if int(sym.Section) >= len(bases) || bases[sym.Section] == 0 || !strings.HasSuffix(sym.Name, ".files") {
+			continue
+		}
+		if pkg := extractPackageFromSymbol(sym.Name); pkg != "" {
+			tables = append(tables, Symbol{Name: sym.Name, Size: sym.Size, Address: bases[sym.Section] + sym.Value, Package: pkg})
+		}
+	}
+	return readEmbedTables(img, tables, mainPath), nil
+}
+
+// readEmbedTables 从 img 中读出 tables 指向的文件表，按包汇总
+func readEmbedTables(img *imageReader, tables []Symbol, mainPath string) []*EmbedReport {
+	byPackage := make(map[string]*EmbedReport)
+	ptr := uint64(img.ptrSize)
+	entrySize := 4*ptr + 16
+	for _, sym := range tables {
+		header, err := img.read(sym.Address, 3*ptr)
+		if err != nil {
+			continue
+		}
+		n := img.ptr(header[ptr:])
+		if n > maxEmbedFiles {
+			continue
+		}
+		// 装不下文件表的不是 embed.FS 的文件表，只是恰好叫 files 的变量；
+		// Mach-O 符号大小取自地址间隔，可能包含对齐填充，所以只要求不小于文件表
+		if sym.Size > 0 && sym.Size < 3*ptr+n*entrySize {
+			continue
+		}
+		entries, err := img.read(img.ptr(header), n*entrySize)
+		if err != nil {
+			continue
+		}
+
+		pkg := sym.Package
+		if pkg == "main" && mainPath != "" {
+			pkg = mainPath
+		}
+		variable := strings.TrimSuffix(strings.TrimPrefix(sym.Name, sym.Package+"."), ".files")
+		for i := uint64(0); i < n; i++ {
+			entry := entries[i*entrySize:]
+			name, err := img.readString(entry)
+			if err != nil || strings.HasSuffix(name, "/") {
+				continue // 目录
+			}
+			addEmbeddedFile(byPackage, pkg, &EmbeddedFile{Name: name, Variable: variable, Size: img.ptr(entry[3*ptr:])})
+		}
+	}
+	return sortedEmbeds(byPackage)
+}
+
+// AnalyzeEmbeds 在二进制文件中的 embed.FS 文件表之外，用 go list 的 EmbedFiles 补上
+// 嵌入为 string 或 []byte 的文件（大小取自磁盘上的文件）
+// args 会原样传给 go list；为空时使用二进制文件中记录的主包路径
+func (g *GoWeight) AnalyzeEmbeds(report *Report, args ...string) ([]*EmbedReport, error) {
+	if len(args) == 0 {
+		args = []string{report.Build.Path}
+	}
+	listed, err := goListDeps(args...)
+	if err != nil {
+		return report.Embeds, err
+	}
+
+	linked := make(map[string]bool)
+	for _, p := range report.Packages() {
+		linked[p.Path] = true
+	}
+	byPackage := make(map[string]*EmbedReport)
+	known := make(map[string]bool)
+	for _, e := range report.Embeds {
+		for _, f := range e.Files {
+			addEmbeddedFile(byPackage, e.Package, f)
+			known[e.Package+"\x00"+f.Name] = true
+		}
+	}
+	for _, p := range listed {
+		if !linked[p.ImportPath] {
+			continue
+		}
+		for _, name := range p.EmbedFiles {
+			if known[p.ImportPath+"\x00"+name] {
+				continue
+			}
+			stat, err := os.Stat(filepath.Join(p.Dir, name))
+			if err != nil {
+				continue
+			}
+			addEmbeddedFile(byPackage, p.ImportPath, &EmbeddedFile{Name: name, Size: uint64(stat.Size())})
+		}
+	}
+	return sortedEmbeds(byPackage), nil
+}
+
+func addEmbeddedFile(byPackage map[string]*EmbedReport, pkg string, f *EmbeddedFile) {
+	e, ok := byPackage[pkg]
+	if !ok {
+		e = &EmbedReport{Package: pkg}
+		byPackage[pkg] = e
+	}
+	f.SizeHuman = humanize.Bytes(f.Size)
+	e.Files = append(e.Files, f)
+	e.Size += f.Size
+}
+
+// sortedEmbeds 按嵌入总大小降序排列，每个包中的文件也按大小降序排列
+func sortedEmbeds(byPackage map[string]*EmbedReport) []*EmbedReport {
+	result := make([]*EmbedReport, 0, len(byPackage))
+	for _, e := range byPackage {
+		e.SizeHuman = humanize.Bytes(e.Size)
+		sort.Slice(e.Files, func(i, j int) bool {
+			if e.Files[i].Size != e.Files[j].Size {
+				return e.Files[i].Size > e.Files[j].Size
+			}
+			return e.Files[i].Name < e.Files[j].Name
+		})
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Package < result[j].Package
+	})
+	return result
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+const embedMain = `package main
+
+import (
+	"embed"
+	"fmt"
+)
+
+//go:embed static
+var static embed.FS
+
+func main() {
+	b, _ := static.ReadFile("static/a.txt")
+	fmt.Println(len(b))
+}
+`
+
+func TestReadEmbedFS(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{
+		"main.go":         embedMain,
+		"static/a.txt":    strings.Repeat("a", 3000),
+		"static/sub/b.js": strings.Repeat("b", 100),
+	}, nil)
+
+	report, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Embeds) != 1 {
+		t.Fatalf("embeds = %+v", report.Embeds)
+	}
+	e := report.Embeds[0]
+	if e.Package != "example.com/app" || e.Size != 3100 || report.Totals.Embedded != 3100 {
+		t.Errorf("embed = %+v, total %d", e, report.Totals.Embedded)
+	}
+	want := []EmbeddedFile{
+		{Name: "static/a.txt", Variable: "static", Size: 3000},
+		{Name: "static/sub/b.js", Variable: "static", Size: 100},
+	}
+	if len(e.Files) != len(want) {
+		t.Fatalf("files = %+v", e.Files)
+	}
+	for i, f := range e.Files {
+		if f.Name != want[i].Name || f.Variable != want[i].Variable || f.Size != want[i].Size {
+			t.Errorf("file %d = %+v, want %+v", i, f, want[i])
+		}
+	}
+}
+
+func TestReadEmbedTables(t *testing.T) {
+	// 0x1000 处是切片头，0x1100 处是唯一的条目 {name, data, hash}，0x1200 处是文件名
+	mem := make([]byte, 0x300)
+	put := func(addr uint64, values ...uint64) {
+		for i, v := range values {
+			binary.LittleEndian.PutUint64(mem[addr-0x1000+uint64(i)*8:], v)
+		}
+	}
+	put(0x1000, 0x1100, 1, 1)
+	put(0x1100, 0x1200, 5, 0x1280, 7)
+	copy(mem[0x200:], "a.txt")
+	img := &imageReader{order: binary.LittleEndian, ptrSize: 8, regions: []*imageRegion{
+		{addr: 0x1000, size: uint64(len(mem)), load: func() ([]byte, error) { return mem, nil }},
+	}}
+
+	tests := []struct {
+		size uint64
+		want bool
+	}{
+		{0, true},   // 大小未知
+		{72, true},  // 切片头 24 字节加一个 48 字节的条目
+		{80, true},  // Mach-O 中包含对齐填充
+		{64, false}, // 装不下文件表
+	}
+	for _, tt := range tests {
+		sym := Symbol{Name: "main.static.files", Size: tt.size, Address: 0x1000, Package: "main"}
+		embeds := readEmbedTables(img, []Symbol{sym}, "example.com/app")
+		if got := len(embeds) == 1; got != tt.want {
+			t.Errorf("size %d: embeds = %+v, want found %v", tt.size, embeds, tt.want)
+			continue
+		}
+		if tt.want {
+			if e := embeds[0]; e.Package != "example.com/app" || len(e.Files) != 1 || e.Files[0].Name != "a.txt" || e.Files[0].Size != 7 {
+				t.Errorf("size %d: embed = %+v", tt.size, e)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package pkg
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// machoArches 把 Mach-O 的 CPU 类型映射到 GOARCH
+var machoArches = map[macho.Cpu]string{
+	macho.Cpu386:   "386",
+	macho.CpuAmd64: "amd64",
+	macho.CpuArm:   "arm",
+	macho.CpuArm64: "arm64",
+	macho.CpuPpc:   "ppc",
+	macho.CpuPpc64: "ppc64",
+}
+
+// machoArchAliases 是 lipo 等 Apple 工具使用的架构名
+var machoArchAliases = map[string]string{
+	"x86_64": "amd64",
+	"i386":   "386",
+}
+
+// fatSlice 是通用二进制文件中一个架构的切片
+type fatSlice struct {
+	Arch   string
+	Offset int64
+	Size   int64
+}
+
+// binaryFile 是打开的二进制文件；通用二进制文件只暴露选中的切片
+type binaryFile struct {
+	*io.SectionReader
+	file *os.File
+	// Arch 是选中的切片的架构，不是通用二进制文件时为空
+	Arch string
+}
+
+func (f *binaryFile) Close() error {
+	return f.file.Close()
+}
+
+// FatArches 返回 Mach-O 通用二进制文件（fat binary）中各切片的架构，按文件中的顺序排列
+// 不是通用二进制文件时返回 nil
+func FatArches(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	slices, err := readFatSlices(f)
+	if err != nil {
+		return nil, err
+	}
+	var arches []string
+	for _, s := range slices {
+		arches = append(arches, s.Arch)
+	}
+	return arches, nil
+}
+
+// readFatSlices 读出通用二进制文件的切片表，不是通用二进制文件时返回 nil
+func readFatSlices(r io.ReaderAt) ([]fatSlice, error) {
+	// NewFatFile 只对单一架构的 Mach-O 文件返回 ErrNotFat，其他格式先按魔数排除
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil || binary.BigEndian.Uint32(magic[:]) != macho.MagicFat {
+		return nil, nil
+	}
+	ff, err := macho.NewFatFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer ff.Close()
+	var slices []fatSlice
+	for _, a := range ff.Arches {
+		arch, ok := machoArches[a.Cpu]
+		if !ok {
+			arch = strings.TrimPrefix(a.Cpu.String(), "Cpu")
+		}
+		slices = append(slices, fatSlice{Arch: arch, Offset: int64(a.Offset), Size: int64(a.Size)})
+	}
+	return slices, nil
+}
+
+// openBinary 打开二进制文件；通用二进制文件选取 arch 对应的切片，
+// arch 为空时优先选当前平台的架构，没有的话选第一个切片
+// 普通二进制文件忽略 arch
+func openBinary(path, arch string) (*binaryFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	slices, err := readFatSlices(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if slices == nil {
+		return &binaryFile{SectionReader: io.NewSectionReader(f, 0, stat.Size()), file: f}, nil
+	}
+
+	slice, err := selectSlice(slices, arch)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &binaryFile{SectionReader: io.NewSectionReader(f, slice.Offset, slice.Size), file: f, Arch: slice.Arch}, nil
+}
+
+func selectSlice(slices []fatSlice, arch string) (fatSlice, error) {
+	if alias, ok := machoArchAliases[arch]; ok {
+		arch = alias
+	}
+	want := arch
+	if want == "" {
+		want = runtime.GOARCH
+	}
+	var arches []string
+	for _, s := range slices {
+		if s.Arch == want {
+			return s, nil
+		}
+		arches = append(arches, s.Arch)
+	}
+	if arch == "" {
+		return slices[0], nil
+	}
+	return fatSlice{}, fmt.Errorf("universal binary has no %s slice (has %s)", arch, strings.Join(arches, ", "))
+}
+
+// AnalyzeArches 分别分析通用二进制文件中的每个切片，返回的报告按文件中的切片顺序排列
+// 普通二进制文件和设置了 Arch 的情况只返回一个报告
+func (g *GoWeight) AnalyzeArches(binaryPath string) ([]*Report, error) {
+	arches, err := FatArches(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(arches) == 0 || g.Arch != "" {
+		report, err := g.AnalyzeBinary(binaryPath)
+		if err != nil {
+			return nil, err
+		}
+		return []*Report{report}, nil
+	}
+
+	var reports []*Report
+	for _, arch := range arches {
+		slice := *g
+		slice.Arch = arch
+		report, err := slice.AnalyzeBinary(binaryPath)
+		if err != nil {
+			return nil, fmt.Errorf("analyzing %s slice: %w", arch, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// UniversalReport 把 AnalyzeArches 返回的各切片报告合成一个报告：顶层是没有指定架构时
+// AnalyzeBinary 选中的切片（当前平台的架构，没有的话是第一个），Slices 是全部切片
+func UniversalReport(reports []*Report) *Report {
+	top := *reports[0]
+	for _, r := range reports {
+		if r.Binary.Arch == runtime.GOARCH {
+			top = *r
+			break
+		}
+	}
+	top.Slices = reports
+	return &top
+}
+
+// ArchRow 是按架构对比的一行，Sizes 与 reports 一一对应，切片中没有这个分组时为 0
+type ArchRow struct {
+	Name  string   `json:"name"`
+	Sizes []uint64 `json:"sizes"`
+}
+
+// ArchRows 把每个切片的报告按同样的规则分组后并排，按各架构中最大的大小降序排列
+func ArchRows(reports []*Report, gr Grouping, keep func(*ModuleReport) bool) []*ArchRow {
+	rows := make(map[string]*ArchRow)
+	var order []*ArchRow
+	for i, report := range reports {
+		for _, entry := range report.GroupPackages(gr, keep) {
+			row, ok := rows[entry.Name]
+			if !ok {
+				row = &ArchRow{Name: entry.Name, Sizes: make([]uint64, len(reports))}
+				rows[entry.Name] = row
+				order = append(order, row)
+			}
+			row.Sizes[i] += entry.Size
+		}
+	}
+	largest := func(row *ArchRow) uint64 {
+		var m uint64
+		for _, size := range row.Sizes {
+			m = max(m, size)
+		}
+		return m
+	}
+	sort.SliceStable(order, func(i, j int) bool { return largest(order[i]) > largest(order[j]) })
+	return order
+}
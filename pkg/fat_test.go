@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSelectSlice(t *testing.T) {
+	slices := []fatSlice{{Arch: "amd64", Offset: 4096}, {Arch: "arm64", Offset: 8192}}
+	host := slices[0]
+	if runtime.GOARCH == "arm64" {
+		host = slices[1]
+	}
+	tests := []struct {
+		arch    string
+		want    fatSlice
+		wantErr bool
+	}{
+		{"arm64", slices[1], false},
+		{"x86_64", slices[0], false},
+		{"amd64", slices[0], false},
+		{"", host, false},
+		{"riscv64", fatSlice{}, true},
+	}
+	for _, tt := range tests {
+		got, err := selectSlice(slices, tt.arch)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("selectSlice(%q) = %+v, %v; want %+v, error %v", tt.arch, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// writeFatBinary 把几个单一架构的 Mach-O 文件合成一个通用二进制文件，切片按 4096 字节对齐
+func writeFatBinary(t *testing.T, path string, thin ...string) {
+	t.Helper()
+	const align = 12
+	header := binary.BigEndian.AppendUint32(nil, macho.MagicFat)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(thin)))
+	var body []byte
+	offset := uint32(1 << align)
+	for _, name := range thin {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := macho.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cpu, sub := f.Cpu, f.SubCpu
+		f.Close()
+		for _, v := range []uint32{uint32(cpu), sub, offset, uint32(len(data)), align} {
+			header = binary.BigEndian.AppendUint32(header, v)
+		}
+		body = append(body, make([]byte, int(offset)-len(body)-(1<<align))...)
+		body = append(body, data...)
+		offset = uint32((1<<align)+len(body)+(1<<align)-1) &^ (1<<align - 1)
+	}
+	out := append(header, make([]byte, (1<<align)-len(header))...)
+	if err := os.WriteFile(path, append(out, body...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMachOSymbolSizes(t *testing.T) {
+	linux := buildTestBinary(t, map[string]string{"main.go": helloMain}, []string{"GOOS=linux", "GOARCH=arm64"})
+	darwin := buildTestBinary(t, map[string]string{"main.go": helloMain}, []string{"GOOS=darwin", "GOARCH=arm64"})
+
+	elfReport, err := (&GoWeight{}).AnalyzeBinary(linux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := (&GoWeight{}).AnalyzeBinary(darwin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Binary.Format != "MachO" || report.Source != SourceSymtab {
+		t.Errorf("format = %q, source = %q", report.Binary.Format, report.Source)
+	}
+	if report.Totals.Strings == 0 {
+		t.Error("no string literal bytes")
+	}
+
+	sizes := make(map[string]uint64)
+	for _, p := range elfReport.Packages() {
+		sizes[p.Path] = p.Size
+	}
+	// 地址差包含对齐填充，和 ELF 符号表中的大小相差不多
+	for _, p := range report.Packages() {
+		switch p.Path {
+		case "fmt", "runtime", "strconv":
+			if want := sizes[p.Path]; p.Size < want*8/10 || p.Size > want*12/10 {
+				t.Errorf("package %s: %d bytes in Mach-O, %d in ELF", p.Path, p.Size, want)
+			}
+		}
+	}
+
+	// 符号不会超出所在的节，也不会互相重叠
+	f, err := macho.Open(darwin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	symbols, _ := machoSymbols(f)
+	var total uint64
+	for _, sym := range symbols {
+		total += sym.Size
+		// __TEXT 和 __DATA_CONST 中都可能有 __rodata 节
+		inside := false
+		for _, sec := range f.Sections {
+			if sec.Name == sym.Section && sym.Address >= sec.Addr && sym.Address+sym.Size <= sec.Addr+sec.Size {
+				inside = true
+			}
+		}
+		if !inside {
+			t.Errorf("symbol %s (%#x+%d) is outside section %s", sym.Name, sym.Address, sym.Size, sym.Section)
+		}
+		if sym.Section == "__bss" || sym.Section == "__noptrbss" {
+			t.Errorf("zero-fill symbol %s has file bytes", sym.Name)
+		}
+	}
+	if total > uint64(report.Binary.Size) {
+		t.Errorf("symbols cover %d bytes of a %d-byte file", total, report.Binary.Size)
+	}
+}
+
+func TestAnalyzeArches(t *testing.T) {
+	amd64 := buildTestBinary(t, map[string]string{"main.go": helloMain}, []string{"GOOS=darwin", "GOARCH=amd64"})
+	arm64 := buildTestBinary(t, map[string]string{"main.go": helloMain}, []string{"GOOS=darwin", "GOARCH=arm64"})
+	fat := filepath.Join(t.TempDir(), "app")
+	writeFatBinary(t, fat, amd64, arm64)
+
+	arches, err := FatArches(fat)
+	if err != nil || len(arches) != 2 || arches[0] != "amd64" || arches[1] != "arm64" {
+		t.Fatalf("FatArches = %v, %v", arches, err)
+	}
+	if arches, err := FatArches(amd64); err != nil || arches != nil {
+		t.Errorf("FatArches of a thin binary = %v, %v", arches, err)
+	}
+
+	reports, err := (&GoWeight{}).AnalyzeArches(fat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0].Binary.Arch != "amd64" || reports[1].Binary.Arch != "arm64" || reports[0].Build.GOARCH != "amd64" {
+		t.Fatalf("reports = %+v", reports)
+	}
+	universal := UniversalReport(reports)
+	want := "amd64" // 当前平台的架构不在文件中时选第一个切片
+	if runtime.GOARCH == "arm64" {
+		want = "arm64"
+	}
+	if universal.Binary.Arch != want {
+		t.Errorf("universal report shows the %s slice, want %s", universal.Binary.Arch, want)
+	}
+	if len(universal.Slices) != 2 || universal.Slices[0] != reports[0] || reports[0].Slices != nil || reports[1].Slices != nil {
+		t.Errorf("slices = %+v", universal.Slices)
+	}
+
+	rows := ArchRows(reports, Grouping{Mode: GroupByModule}, nil)
+	if len(rows) == 0 || rows[0].Sizes[0] == 0 || rows[0].Sizes[1] == 0 {
+		t.Errorf("rows = %+v", rows)
+	}
+
+	report, err := (&GoWeight{Arch: "x86_64"}).AnalyzeBinary(fat)
+	if err != nil || report.Binary.Arch != "amd64" {
+		t.Errorf("--arch x86_64: %v, %v", report, err)
+	}
+	if _, err := (&GoWeight{Arch: "riscv64"}).AnalyzeBinary(fat); err == nil {
+		t.Error("missing slice did not fail")
+	}
+}
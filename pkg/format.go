@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// csvHeader 是 CSV/TSV 输出的固定表头，新增列只能追加在末尾
+var csvHeader = []string{"name", "version", "size", "size_human", "percent", "cumulative_percent", "metadata", "memory"}
+
+// tableRow 是表格类输出中的一行
+type tableRow struct {
+	entry      *ModuleEntry
+	percent    float64
+	cumulative float64
+}
+
+// tableRows 计算每个条目占总大小的百分比和累计百分比，条目需已按大小降序排列
+func tableRows(entries []*ModuleEntry) []tableRow {
+	var total uint64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	rows := make([]tableRow, 0, len(entries))
+	var running uint64
+	for _, e := range entries {
+		running += e.Size
+		row := tableRow{entry: e}
+		if total > 0 {
+			row.percent = float64(e.Size) * 100 / float64(total)
+			row.cumulative = float64(running) * 100 / float64(total)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WriteMarkdown 把条目输出为 GitHub 风格的 Markdown 表格
+func WriteMarkdown(w io.Writer, entries []*ModuleEntry) error {
+	var b strings.Builder
+	b.WriteString("| Size | % | Cumulative % | Metadata | Memory | Name | Version |\n")
+	b.WriteString("|-----:|--:|-------------:|---------:|-------:|------|---------|\n")
+	for _, row := range tableRows(entries) {
+		fmt.Fprintf(&b, "| %s | %.2f%% | %.2f%% | %s | %s | %s | %s |\n",
+			row.entry.SizeHuman,
+			row.percent,
+			row.cumulative,
+			humanize.Bytes(row.entry.Metadata),
+			humanize.Bytes(row.entry.Memory),
+			markdownEscape(row.entry.Name),
+			markdownEscape(row.entry.Version))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCSV 把条目输出为带固定表头的 CSV，comma 为 '\t' 时输出 TSV
+func WriteCSV(w io.Writer, entries []*ModuleEntry, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, row := range tableRows(entries) {
+		record := []string{
+			row.entry.Name,
+			row.entry.Version,
+			strconv.FormatUint(row.entry.Size, 10),
+			row.entry.SizeHuman,
+			strconv.FormatFloat(row.percent, 'f', 2, 64),
+			strconv.FormatFloat(row.cumulative, 'f', 2, 64),
+			strconv.FormatUint(row.entry.Metadata, 10),
+			strconv.FormatUint(row.entry.Memory, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markdownEscape 转义会破坏表格结构的字符
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
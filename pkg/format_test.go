@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testEntries() []*ModuleEntry {
+	return []*ModuleEntry{
+		{Name: "github.com/a/b", Version: "v1.2.0", Size: 600, SizeHuman: "600 B", Memory: 4096},
+		{Name: "golang.org/x/a|b", Version: "v0.1.0", Size: 300, SizeHuman: "300 B"},
+		{Name: "example.com/c", Size: 100, SizeHuman: "100 B"},
+	}
+}
+
+func TestTableRows(t *testing.T) {
+	rows := tableRows(testEntries())
+	tests := []struct {
+		percent    float64
+		cumulative float64
+	}{
+		{60, 60},
+		{30, 90},
+		{10, 100},
+	}
+	for i, tt := range tests {
+		if rows[i].percent != tt.percent || rows[i].cumulative != tt.cumulative {
+			t.Errorf("row %d = %.2f%%/%.2f%%, want %.2f%%/%.2f%%", i, rows[i].percent, rows[i].cumulative, tt.percent, tt.cumulative)
+		}
+	}
+
+	if rows := tableRows([]*ModuleEntry{{Name: "empty"}}); rows[0].percent != 0 {
+		t.Errorf("percent of an empty total = %.2f, want 0", rows[0].percent)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	for _, comma := range []rune{',', '\t'} {
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, testEntries(), comma); err != nil {
+			t.Fatal(err)
+		}
+		r := csv.NewReader(&buf)
+		r.Comma = comma
+		records, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(records[0], csvHeader) {
+			t.Errorf("header = %v, want %v", records[0], csvHeader)
+		}
+		want := [][]string{
+			{"github.com/a/b", "v1.2.0", "600", "600 B", "60.00", "60.00", "0", "4096"},
+			{"golang.org/x/a|b", "v0.1.0", "300", "300 B", "30.00", "90.00", "0", "0"},
+			{"example.com/c", "", "100", "100 B", "10.00", "100.00", "0", "0"},
+		}
+		for i, w := range want {
+			if got := records[i+1]; !reflect.DeepEqual(got, w) {
+				t.Errorf("comma %q: record %d = %v, want %v", comma, i, got, w)
+			}
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, testEntries()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want header, separator and 3 rows:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[3], `golang.org/x/a\|b`) {
+		t.Errorf("pipe in name is not escaped: %s", lines[3])
+	}
+	if !strings.HasPrefix(lines[2], "| 600 B | 60.00% | 60.00% | 0 B | 4.1 kB |") {
+		t.Errorf("first row = %s", lines[2])
+	}
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// GenericReport 是一个泛型函数的所有实例化
+type GenericReport struct {
+	Function  string `json:"function"`
+	Package   string `json:"package"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+	// Instantiations 是实例化出的函数数量，Dictionaries 是运行时字典的数量
+	Instantiations int `json:"instantiations"`
+	Dictionaries   int `json:"dictionaries"`
+	// Largest 是按大小降序排列的实例化符号（含字典）
+	Largest []*Symbol `json:"largest"`
+}
+
+// GenericInstantiations 按原始泛型函数汇总报告中的实例化符号，总大小最大的排在最前
+// 报告必须包含符号（没有经过 WithoutSymbols）
+func (r *Report) GenericInstantiations() []*GenericReport {
+	byFunction := make(map[string]*GenericReport)
+	for _, module := range r.Modules {
+		for _, p := range module.Packages {
+			for _, sym := range p.Symbols {
+				if sym.Generic == "" {
+					continue
+				}
+				g, ok := byFunction[sym.Generic]
+				if !ok {
+					g = &GenericReport{Function: sym.Generic, Package: p.Path}
+					byFunction[sym.Generic] = g
+				}
+				g.Size += sym.Size
+				if strings.Contains(sym.Name, "..dict.") {
+					g.Dictionaries++
+				} else {
+					g.Instantiations++
+				}
+				g.Largest = append(g.Largest, sym)
+			}
+		}
+	}
+
+	result := make([]*GenericReport, 0, len(byFunction))
+	for _, g := range byFunction {
+		g.SizeHuman = humanize.Bytes(g.Size)
+		sort.SliceStable(g.Largest, func(i, j int) bool { return g.Largest[i].Size > g.Largest[j].Size })
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Function < result[j].Function
+	})
+	return result
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+const genericsMain = `package main
+
+import "fmt"
+
+type List[T any] struct{ items []T }
+
+func (l *List[T]) Push(v T) { l.items = append(l.items, v) }
+
+//go:noinline
+func Map[T, U any](in []T, f func(T) U) []U {
+	out := make([]U, 0, len(in))
+	for _, v := range in {
+		out = append(out, f(v))
+	}
+	return out
+}
+
+func main() {
+	var a List[int]
+	var b List[string]
+	a.Push(1)
+	b.Push("x")
+	fmt.Println(a, b, Map([]int{1}, func(i int) string { return fmt.Sprint(i) }), Map([]string{"a"}, func(s string) int { return len(s) }))
+}
+`
+
+func TestGenericInstantiations(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{"main.go": genericsMain}, nil)
+	report, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origins := make(map[string]*GenericReport)
+	for _, g := range report.GenericInstantiations() {
+		origins[g.Function] = g
+		if strings.HasPrefix(g.Function, "type:") || strings.HasPrefix(g.Function, "go:itab.") {
+			t.Errorf("type descriptor %q is reported as a generic function", g.Function)
+		}
+	}
+	if g := origins["main.Map"]; g == nil || g.Size == 0 {
+		t.Errorf("main.Map is missing from the generics report: %v", origins)
+	}
+	for _, p := range report.Packages() {
+		for _, sym := range p.Symbols {
+			if sym.Kind == KindType && sym.Generic != "" {
+				t.Errorf("type descriptor %s has generic origin %q", sym.Name, sym.Generic)
+			}
+		}
+	}
+}
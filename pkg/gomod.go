@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/mod/modfile"
+)
+
+// 模块的来源分类
+const (
+	OriginStd      = "std"      // 标准库
+	OriginMain     = "main"     // 主模块
+	OriginDirect   = "direct"   // go.mod 中直接依赖的模块
+	OriginIndirect = "indirect" // go.mod 中标记为 // indirect 或不在 go.mod 中的模块
+	// OriginDependency 是没有主模块 go.mod 时依赖模块的分类，无法区分直接和间接依赖
+	OriginDependency = "dependency"
+	OriginCgo        = "cgo" // 无法归属到 Go 包的 C 代码（<cgo> 伪模块）
+	// OriginUnknown 是既不属于 buildinfo 中的模块、也不是标准库的包（<unknown> 伪模块），如 GOPATH 模式构建的包
+	OriginUnknown = "unknown"
+)
+
+// UnknownModule 是既不属于任何已知模块、也不在标准库中的包所在的伪模块
+const UnknownModule = "<unknown>"
+
+// Origins 是 --only 可以选择的分类
+var Origins = []string{OriginStd, OriginMain, OriginDirect, OriginIndirect, OriginDependency, OriginCgo, OriginUnknown}
+
+// Requirement 是主模块 go.mod 中的一条 require
+type Requirement struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Indirect bool   `json:"indirect,omitempty"`
+}
+
+// FindGoMod 返回当前目录所在模块的 go.mod 路径（go env GOMOD）
+func FindGoMod() (string, error) {
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		return "", fmt.Errorf("go env GOMOD: %w", err)
+	}
+	path := strings.TrimSpace(string(out))
+	if path == "" || path == os.DevNull {
+		return "", errors.New("the current directory is not in a module")
+	}
+	return path, nil
+}
+
+// ReadGoMod 读取 path 处的 go.mod 中的依赖，其模块路径必须是 mainModule
+func ReadGoMod(path, mainModule string) ([]Requirement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := modfile.ParseLax(path, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if file.Module == nil || file.Module.Mod.Path != mainModule {
+		return nil, fmt.Errorf("%s is not the go.mod of %s", path, mainModule)
+	}
+
+	requirements := []Requirement{}
+	for _, r := range file.Require {
+		requirements = append(requirements, Requirement{Path: r.Mod.Path, Version: r.Mod.Version, Indirect: r.Indirect})
+	}
+	return requirements, nil
+}
+
+// LoadStdPackages 用 go list std 列出本地工具链的标准库包（含 vendor/ 下的包）
+func LoadStdPackages() (map[string]bool, error) {
+	cmd := exec.Command("go", "list", "std")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list std: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	std := make(map[string]bool)
+	for _, path := range strings.Fields(string(out)) {
+		std[path] = true
+	}
+	return std, nil
+}
+
+// ClassifyOrigins 给报告中的每个模块标注来源分类
+// requirements 是主模块 go.mod 中的依赖（见 ReadGoMod），为 nil 时依赖模块标为 dependency；
+// std 是标准库包（见 LoadStdPackages），不为 nil 时把没有归属到模块、也不在其中的包从 std 移到 <unknown>
+func (r *Report) ClassifyOrigins(requirements []Requirement, std map[string]bool) {
+	if std != nil {
+		r.Modules = splitUnknown(r.Modules, std)
+		r.Totals.Modules = len(r.Modules)
+	}
+	classifyOrigins(r.Modules, requirements)
+}
+
+// splitUnknown 把 std 模块中不是标准库的包移到 <unknown> 伪模块
+func splitUnknown(modules []*ModuleReport, std map[string]bool) []*ModuleReport {
+	unknown := &ModuleReport{Path: UnknownModule}
+	for _, module := range modules {
+		if module.Path != StdModule {
+			continue
+		}
+		var kept []*PackageReport
+		for _, p := range module.Packages {
+			// go.shape 等以 go. 开头的是编译器生成的类型，属于工具链
+			if std[p.Path] || p.Path == "go" || strings.HasPrefix(p.Path, "go.") {
+				kept = append(kept, p)
+				continue
+			}
+			unknown.Packages = append(unknown.Packages, p)
+			unknown.Size += p.Size
+			unknown.Metadata += p.Metadata
+			unknown.Memory += p.Memory
+			module.Size -= p.Size
+			module.Metadata -= p.Metadata
+			module.Memory -= p.Memory
+		}
+		module.Packages = kept
+		module.SizeHuman = humanize.Bytes(module.Size)
+	}
+	if len(unknown.Packages) == 0 {
+		return modules
+	}
+	unknown.SizeHuman = humanize.Bytes(unknown.Size)
+	modules = append(modules, unknown)
+	sort.SliceStable(modules, func(i, j int) bool { return modules[i].Size > modules[j].Size })
+	return modules
+}
+
+// classifyOrigins 给模块标注来源分类
+// 依赖模块是否直接依赖取决于主模块 go.mod 中的 // indirect 标记
+func classifyOrigins(modules []*ModuleReport, requirements []Requirement) {
+	direct := make(map[string]bool)
+	for _, r := range requirements {
+		if !r.Indirect {
+			direct[r.Path] = true
+		}
+	}
+	for _, module := range modules {
+		switch {
+		case module.Path == StdModule:
+			module.Origin = OriginStd
+		case module.Path == CgoPackage:
+			module.Origin = OriginCgo
+		case module.Path == UnknownModule:
+			module.Origin = OriginUnknown
+		case module.Main:
+			module.Origin = OriginMain
+		case requirements == nil:
+			module.Origin = OriginDependency
+		case direct[module.Path]:
+			module.Origin = OriginDirect
+		default:
+			module.Origin = OriginIndirect
+		}
+	}
+}
+
+// OriginTotals 按来源分类汇总模块大小，keep 为 nil 时包含所有模块
+func (r *Report) OriginTotals(keep func(*ModuleReport) bool) map[string]uint64 {
+	totals := make(map[string]uint64)
+	for _, module := range r.Modules {
+		if keep == nil || keep(module) {
+			totals[module.Origin] += module.Size
+		}
+	}
+	return totals
+}
@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeGoMod(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "go.mod")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadGoMod(t *testing.T) {
+	path := writeGoMod(t, `module example.com/app
+
+go 1.24
+
+require (
+	github.com/a/direct v1.0.0
+	github.com/b/indirect v0.2.0 // indirect
+)
+`)
+	requirements, err := ReadGoMod(path, "example.com/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Requirement{
+		{Path: "github.com/a/direct", Version: "v1.0.0"},
+		{Path: "github.com/b/indirect", Version: "v0.2.0", Indirect: true},
+	}
+	if !reflect.DeepEqual(requirements, want) {
+		t.Errorf("ReadGoMod = %+v, want %+v", requirements, want)
+	}
+
+	if _, err := ReadGoMod(path, "example.com/other"); err == nil || !strings.Contains(err.Error(), "is not the go.mod of") {
+		t.Errorf("mismatched module error = %v", err)
+	}
+
+	// 没有依赖的 go.mod 和没有 go.mod 要区分开
+	requirements, err = ReadGoMod(writeGoMod(t, "module example.com/app\n"), "example.com/app")
+	if err != nil || requirements == nil {
+		t.Errorf("go.mod without requirements = %v, %v; want an empty list", requirements, err)
+	}
+}
+
+func TestClassifyOrigins(t *testing.T) {
+	requirements := []Requirement{
+		{Path: "github.com/a/direct"},
+		{Path: "github.com/b/indirect", Indirect: true},
+	}
+	tests := []struct {
+		module       *ModuleReport
+		requirements []Requirement
+		want         string
+	}{
+		{&ModuleReport{Path: StdModule}, requirements, OriginStd},
+		{&ModuleReport{Path: "example.com/app", Main: true}, requirements, OriginMain},
+		{&ModuleReport{Path: CgoPackage}, requirements, OriginCgo},
+		{&ModuleReport{Path: UnknownModule}, requirements, OriginUnknown},
+		{&ModuleReport{Path: "github.com/a/direct"}, requirements, OriginDirect},
+		{&ModuleReport{Path: "github.com/b/indirect"}, requirements, OriginIndirect},
+		// 不在 go.mod 中的模块算间接依赖
+		{&ModuleReport{Path: "github.com/c/missing"}, requirements, OriginIndirect},
+		{&ModuleReport{Path: "github.com/a/direct"}, nil, OriginDependency},
+		{&ModuleReport{Path: "github.com/a/direct"}, []Requirement{}, OriginIndirect},
+	}
+	for _, tt := range tests {
+		classifyOrigins([]*ModuleReport{tt.module}, tt.requirements)
+		if tt.module.Origin != tt.want {
+			t.Errorf("origin of %s with %v = %q, want %q", tt.module.Path, tt.requirements, tt.module.Origin, tt.want)
+		}
+	}
+}
+
+func TestReportClassifyOrigins(t *testing.T) {
+	report := &Report{Modules: []*ModuleReport{
+		{Path: "example.com/app", Main: true, Size: 10, Packages: []*PackageReport{{Path: "example.com/app", Size: 10}}},
+		{Path: StdModule, Size: 1111, Metadata: 5, Packages: []*PackageReport{
+			{Path: "fmt", Size: 1000},
+			{Path: "go.shape.int", Size: 1},
+			{Path: "github.com/gopath/pkg", Size: 100, Metadata: 5},
+			{Path: "internal/new", Size: 10},
+		}},
+	}}
+	std := map[string]bool{"fmt": true, "internal/new": true}
+	report.ClassifyOrigins(nil, std)
+
+	got := make(map[string][]string)
+	sizes := make(map[string]uint64)
+	for _, m := range report.Modules {
+		for _, p := range m.Packages {
+			got[m.Origin] = append(got[m.Origin], p.Path)
+		}
+		sizes[m.Path] = m.Size
+	}
+	want := map[string][]string{
+		OriginMain:    {"example.com/app"},
+		OriginStd:     {"fmt", "go.shape.int", "internal/new"},
+		OriginUnknown: {"github.com/gopath/pkg"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("packages by origin = %v, want %v", got, want)
+	}
+	if sizes[StdModule] != 1011 || sizes[UnknownModule] != 100 {
+		t.Errorf("sizes = %v", sizes)
+	}
+	if report.Totals.Modules != 3 {
+		t.Errorf("totals.modules = %d, want 3", report.Totals.Modules)
+	}
+}
+
+func TestLoadStdPackages(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go list in short mode")
+	}
+	std, err := LoadStdPackages()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, path := range []string{"fmt", "runtime", "internal/abi", "net/http"} {
+		if !std[path] {
+			t.Errorf("%s is not in go list std", path)
+		}
+	}
+	if std["golang.org/x/mod/modfile"] {
+		t.Error("golang.org/x/mod/modfile is listed as std")
+	}
+}
@@ -0,0 +1,301 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+)
+
+// PackageNode 表示导入图中的一个包
+type PackageNode struct {
+	ImportPath string   `json:"import_path"`
+	Module     string   `json:"module,omitempty"`
+	Standard   bool     `json:"standard,omitempty"`
+	Imports    []string `json:"imports,omitempty"`
+	Size       uint64   `json:"size"`
+}
+
+// ImportGraph 是只包含实际链接进二进制文件的包的导入图
+type ImportGraph struct {
+	Roots []string
+	Nodes map[string]*PackageNode
+}
+
+// listedPackage 对应 go list -json 输出中我们关心的字段
+type listedPackage struct {
+	ImportPath string
+	Name       string
+	Standard   bool
+	DepOnly    bool
+	Dir        string
+	Imports    []string
+	EmbedFiles []string
+	Module     *struct {
+		Path    string
+		Version string
+		Main    bool
+	}
+}
+
+// LoadImportGraph 使用 go list -deps 加载导入图，并用 report 中的包大小标注每个包的链接大小
+// args 会原样传给 go list（如 -tags 和包路径）；为空时使用二进制文件中记录的主包路径
+func LoadImportGraph(report *Report, args ...string) (*ImportGraph, error) {
+	if len(args) == 0 {
+		if report.Build.Path == "" {
+			return nil, fmt.Errorf("%s does not record its main package path", report.Binary.Path)
+		}
+		args = []string{report.Build.Path}
+	}
+
+	listed, err := goListDeps(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]uint64)
+	for _, p := range report.Packages() {
+		sizes[p.Path] = p.Size
+	}
+	return newImportGraph(listed, sizes), nil
+}
+
+// newImportGraph 从 go list 输出的包中保留链接进二进制文件的包（sizes 中非零）和根包，构建导入图
+func newImportGraph(listed []*listedPackage, sizes map[string]uint64) *ImportGraph {
+	all := make(map[string]*listedPackage)
+	var roots []string
+	for _, p := range listed {
+		all[p.ImportPath] = p
+		if !p.DepOnly {
+			roots = append(roots, p.ImportPath)
+		}
+	}
+
+	graph := &ImportGraph{Nodes: make(map[string]*PackageNode)}
+	for _, p := range listed {
+		if sizes[p.ImportPath] == 0 && p.DepOnly {
+			continue // 没有链接进二进制文件
+		}
+		node := &PackageNode{
+			ImportPath: p.ImportPath,
+			Standard:   p.Standard,
+			Size:       sizes[p.ImportPath],
+		}
+		if p.Module != nil {
+			node.Module = p.Module.Path
+		}
+		graph.Nodes[p.ImportPath] = node
+	}
+	for _, root := range roots {
+		if _, ok := graph.Nodes[root]; ok {
+			graph.Roots = append(graph.Roots, root)
+		}
+	}
+
+	// 对于未链接的中间包（如只有常量和类型的包），把边穿透连接到它们下面已链接的包
+	for path, node := range graph.Nodes {
+		seen := map[string]bool{path: true}
+		stack := append([]string(nil), all[path].Imports...)
+		for len(stack) > 0 {
+			imp := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[imp] {
+				continue
+			}
+			seen[imp] = true
+			if _, linked := graph.Nodes[imp]; linked {
+				node.Imports = append(node.Imports, imp)
+			} else if p, ok := all[imp]; ok {
+				stack = append(stack, p.Imports...)
+			}
+		}
+		sort.Strings(node.Imports)
+	}
+
+	return graph
+}
+
+// goListDeps 运行 go list -deps -json 并解析输出的 JSON 流
+func goListDeps(args ...string) ([]*listedPackage, error) {
+	cmd := exec.Command("go", append([]string{"list", "-deps", "-json"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list %v: %v\n%s", args, err, stderr.String())
+	}
+
+	var packages []*listedPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		p := &listedPackage{}
+		if err := dec.Decode(p); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("parsing go list output: %w", err)
+		}
+		packages = append(packages, p)
+	}
+	return packages, nil
+}
+
+// Dominators 计算导入图的支配树，返回每个包的直接支配者
+// 直接被根节点（所有 Roots 之上的虚拟节点）支配的包映射为空字符串
+func (ig *ImportGraph) Dominators() map[string]string {
+	// 以虚拟根节点（下标 0）做深度优先遍历，得到后序编号
+	index := map[string]int{"": 0}
+	names := []string{""}
+	var postorder []int
+	visited := map[int]bool{}
+	succs := func(n int) []string {
+		if n == 0 {
+			return ig.Roots
+		}
+		return ig.Nodes[names[n]].Imports
+	}
+	var visit func(n int)
+	visit = func(n int) {
+		visited[n] = true
+		for _, s := range succs(n) {
+			if _, ok := ig.Nodes[s]; !ok {
+				continue
+			}
+			i, ok := index[s]
+			if !ok {
+				i = len(names)
+				index[s] = i
+				names = append(names, s)
+			}
+			if !visited[i] {
+				visit(i)
+			}
+		}
+		postorder = append(postorder, n)
+	}
+	visit(0)
+
+	order := make([]int, len(names)) // 节点下标 -> 后序编号
+	for pos, n := range postorder {
+		order[n] = pos
+	}
+	preds := make([][]int, len(names))
+	for _, n := range postorder {
+		for _, s := range succs(n) {
+			if i, ok := index[s]; ok {
+				preds[i] = append(preds[i], n)
+			}
+		}
+	}
+
+	// Cooper-Harvey-Kennedy 迭代算法
+	idom := make([]int, len(names))
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[0] = 0
+	intersect := func(a, b int) int {
+		for a != b {
+			for order[a] < order[b] {
+				a = idom[a]
+			}
+			for order[b] < order[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := len(postorder) - 1; i >= 0; i-- {
+			n := postorder[i]
+			if n == 0 {
+				continue
+			}
+			newIdom := -1
+			for _, p := range preds[n] {
+				if idom[p] == -1 {
+					continue
+				}
+				if newIdom == -1 {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if newIdom != -1 && idom[n] != newIdom {
+				idom[n] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	result := make(map[string]string, len(names)-1)
+	for i := 1; i < len(names); i++ {
+		if idom[i] >= 0 {
+			result[names[i]] = names[idom[i]]
+		}
+	}
+	return result
+}
+
+// RetainedSizes 计算每个包的保留大小：去掉该包后不再被链接的所有包（含自身）的大小之和
+func (ig *ImportGraph) RetainedSizes() map[string]uint64 {
+	idom := ig.Dominators()
+	children := make(map[string][]string)
+	for n, d := range idom {
+		children[d] = append(children[d], n)
+	}
+
+	retained := make(map[string]uint64, len(idom))
+	var sum func(n string) uint64
+	sum = func(n string) uint64 {
+		total := uint64(0)
+		if node, ok := ig.Nodes[n]; ok {
+			total = node.Size
+		}
+		for _, c := range children[n] {
+			total += sum(c)
+		}
+		retained[n] = total
+		return total
+	}
+	sum("")
+	delete(retained, "")
+	return retained
+}
+
+// ImportChain 返回从某个根包到 target 的最短导入链（包含两端），target 不在图中时返回 nil
+func (ig *ImportGraph) ImportChain(target string) []string {
+	if _, ok := ig.Nodes[target]; !ok {
+		return nil
+	}
+
+	parent := make(map[string]string)
+	queue := make([]string, 0, len(ig.Roots))
+	for _, root := range ig.Roots {
+		if _, seen := parent[root]; !seen {
+			parent[root] = ""
+			queue = append(queue, root)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == target {
+			var chain []string
+			for ; n != ""; n = parent[n] {
+				chain = append([]string{n}, chain...)
+			}
+			return chain
+		}
+		for _, imp := range ig.Nodes[n].Imports {
+			if _, seen := parent[imp]; !seen {
+				parent[imp] = n
+				queue = append(queue, imp)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testGraph 构建 main -> a -> (b, types)、main -> c -> b 的导入图，其中 types 没有链接任何字节
+func testGraph() *ImportGraph {
+	listed := []*listedPackage{
+		{ImportPath: "example.com/app", Imports: []string{"example.com/a", "example.com/c"}},
+		{ImportPath: "example.com/a", DepOnly: true, Imports: []string{"example.com/types"}},
+		{ImportPath: "example.com/types", DepOnly: true, Imports: []string{"example.com/b"}},
+		{ImportPath: "example.com/c", DepOnly: true, Imports: []string{"example.com/b"}},
+		{ImportPath: "example.com/b", DepOnly: true},
+		{ImportPath: "example.com/unused", DepOnly: true},
+	}
+	sizes := map[string]uint64{
+		"example.com/app": 100,
+		"example.com/a":   10,
+		"example.com/b":   1000,
+		"example.com/c":   20,
+	}
+	return newImportGraph(listed, sizes)
+}
+
+func TestNewImportGraph(t *testing.T) {
+	graph := testGraph()
+
+	var nodes []string
+	for path := range graph.Nodes {
+		nodes = append(nodes, path)
+	}
+	sort.Strings(nodes)
+	wantNodes := []string{"example.com/a", "example.com/app", "example.com/b", "example.com/c"}
+	if !reflect.DeepEqual(nodes, wantNodes) {
+		t.Fatalf("nodes = %v, want %v", nodes, wantNodes)
+	}
+	if !reflect.DeepEqual(graph.Roots, []string{"example.com/app"}) {
+		t.Errorf("roots = %v", graph.Roots)
+	}
+
+	tests := []struct {
+		pkg  string
+		want []string
+	}{
+		{"example.com/app", []string{"example.com/a", "example.com/c"}},
+		// 未链接的 types 被穿透，a 直接连到 b
+		{"example.com/a", []string{"example.com/b"}},
+		{"example.com/c", []string{"example.com/b"}},
+		{"example.com/b", nil},
+	}
+	for _, tt := range tests {
+		if got := graph.Nodes[tt.pkg].Imports; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("imports of %s = %v, want %v", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestRetainedSizes(t *testing.T) {
+	retained := testGraph().RetainedSizes()
+	tests := []struct {
+		pkg  string
+		want uint64
+	}{
+		{"example.com/app", 1130},
+		// b 同时被 a 和 c 导入，去掉其中任何一个都不会去掉 b
+		{"example.com/a", 10},
+		{"example.com/c", 20},
+		{"example.com/b", 1000},
+	}
+	for _, tt := range tests {
+		if got := retained[tt.pkg]; got != tt.want {
+			t.Errorf("retained[%s] = %d, want %d", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestImportChain(t *testing.T) {
+	graph := testGraph()
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"example.com/app", []string{"example.com/app"}},
+		{"example.com/b", []string{"example.com/app", "example.com/a", "example.com/b"}},
+		{"example.com/types", nil},
+		{"example.com/missing", nil},
+	}
+	for _, tt := range tests {
+		if got := graph.ImportChain(tt.target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ImportChain(%s) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// 聚合方式
+const (
+	GroupByModule = "module" // 按 buildinfo 中真实的模块边界
+	GroupByDepth  = "depth"  // 按包路径的前 N 段
+	GroupByOrg    = "org"    // 按组织（如 github.com/aws、golang.org/x、k8s.io）
+	GroupByNone   = "none"   // 不聚合，每个包一行
+)
+
+// codeHosts 是路径第二段表示组织或用户的代码托管站点
+var codeHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+	"gitee.com":     true,
+	"codeberg.org":  true,
+	"git.sr.ht":     true,
+}
+
+// Grouping 决定如何把包聚合成输出中的一行
+type Grouping struct {
+	Mode  string
+	Depth int
+	Rules *Rules // Mode 为 GroupByRules 时使用
+}
+
+// ParseGrouping 解析 --group-by 的值：module、depth=N、org 或 none
+func ParseGrouping(spec string) (Grouping, error) {
+	switch spec {
+	case GroupByModule, GroupByOrg, GroupByNone:
+		return Grouping{Mode: spec}, nil
+	}
+	if value, ok := strings.CutPrefix(spec, GroupByDepth+"="); ok {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 1 {
+			return Grouping{}, fmt.Errorf("invalid depth %q: must be a positive integer", value)
+		}
+		return Grouping{Mode: GroupByDepth, Depth: depth}, nil
+	}
+	return Grouping{}, fmt.Errorf("unknown grouping %q (want module, depth=N, org or none)", spec)
+}
+
+// Key 返回包所属的分组名，module 为包所在的模块路径（未知时为空）
+func (gr Grouping) Key(pkgPath, module string) string {
+	switch gr.Mode {
+	case GroupByModule:
+		if module != "" {
+			return module
+		}
+		return pkgPath
+	case GroupByDepth:
+		parts := strings.Split(pkgPath, "/")
+		if len(parts) > gr.Depth {
+			parts = parts[:gr.Depth]
+		}
+		return strings.Join(parts, "/")
+	case GroupByRules:
+		return gr.Rules.Match(pkgPath, module)
+	case GroupByOrg:
+		if module == StdModule {
+			return StdModule
+		}
+		return orgOf(pkgPath)
+	default:
+		return pkgPath
+	}
+}
+
+// orgOf 返回包路径所属的组织
+func orgOf(pkgPath string) string {
+	parts := strings.Split(pkgPath, "/")
+	host := parts[0]
+	switch {
+	case !strings.Contains(host, "."):
+		// 标准库或没有域名的本地模块
+		return host
+	case host == "golang.org" && len(parts) > 1 && parts[1] == "x":
+		return "golang.org/x"
+	case host == "gopkg.in" && len(parts) > 1:
+		// gopkg.in/yaml.v3 -> gopkg.in/yaml，gopkg.in/user/pkg.v1 -> gopkg.in/user
+		name, _, _ := strings.Cut(parts[1], ".")
+		return host + "/" + name
+	case codeHosts[host] && len(parts) > 1:
+		return host + "/" + parts[1]
+	default:
+		// 自定义域名（k8s.io、go.uber.org、google.golang.org 等）本身就是组织
+		return host
+	}
+}
+
+// GroupPackages 按 gr 聚合报告中的包，keep 为 nil 时保留所有模块
+func (r *Report) GroupPackages(gr Grouping, keep func(*ModuleReport) bool) []*ModuleEntry {
+	groups := make(map[string]*ModuleEntry)
+	for _, module := range r.Modules {
+		if keep != nil && !keep(module) {
+			continue
+		}
+		// 估算模式下没有包信息，只能按模块聚合
+		if len(module.Packages) == 0 {
+			addToGroup(groups, gr.Key(module.Path, module.Path), module.Version, module.Origin, module.Size, module.Metadata, module.Memory)
+			continue
+		}
+		for _, p := range module.Packages {
+			version := ""
+			if gr.Mode == GroupByModule || gr.Mode == GroupByNone {
+				version = module.Version
+			}
+			addToGroup(groups, gr.Key(p.Path, module.Path), version, module.Origin, p.Size, p.Metadata, p.Memory)
+		}
+	}
+	return sortedGroups(groups)
+}
+
+// GroupEntries 按 gr 聚合没有模块信息的条目（如构建过程分析的结果）
+func GroupEntries(entries []*ModuleEntry, gr Grouping) []*ModuleEntry {
+	groups := make(map[string]*ModuleEntry)
+	for _, e := range entries {
+		addToGroup(groups, gr.Key(e.Name, ""), e.Version, e.Origin, e.Size, e.Metadata, e.Memory)
+	}
+	return sortedGroups(groups)
+}
+
+// addToGroup 把大小计入分组；分组内版本或来源不一致时清空对应字段
+func addToGroup(groups map[string]*ModuleEntry, key, version, origin string, size, metadata, memory uint64) {
+	if existing, ok := groups[key]; ok {
+		existing.Size += size
+		existing.Metadata += metadata
+		existing.Memory += memory
+		if existing.Version != version {
+			existing.Version = ""
+		}
+		if existing.Origin != origin {
+			existing.Origin = ""
+		}
+		return
+	}
+	groups[key] = &ModuleEntry{Path: key, Name: key, Version: version, Origin: origin, Size: size, Metadata: metadata, Memory: memory}
+}
+
+// sortedGroups 转换为按大小降序排列的切片
+func sortedGroups(groups map[string]*ModuleEntry) []*ModuleEntry {
+	result := make([]*ModuleEntry, 0, len(groups))
+	for _, e := range groups {
+		e.SizeHuman = humanize.Bytes(e.Size)
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
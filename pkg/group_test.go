@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGrouping(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Grouping
+		wantErr bool
+	}{
+		{"module", Grouping{Mode: GroupByModule}, false},
+		{"org", Grouping{Mode: GroupByOrg}, false},
+		{"none", Grouping{Mode: GroupByNone}, false},
+		{"depth=3", Grouping{Mode: GroupByDepth, Depth: 3}, false},
+		{"depth=0", Grouping{}, true},
+		{"depth=x", Grouping{}, true},
+		{"depth", Grouping{}, true},
+		{"team", Grouping{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseGrouping(tt.spec)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseGrouping(%q) = %+v, %v; want %+v, error %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGroupingKey(t *testing.T) {
+	tests := []struct {
+		mode   Grouping
+		pkg    string
+		module string
+		want   string
+	}{
+		{Grouping{Mode: GroupByModule}, "github.com/aws/aws-sdk-go-v2/service/s3/types", "github.com/aws/aws-sdk-go-v2/service/s3", "github.com/aws/aws-sdk-go-v2/service/s3"},
+		{Grouping{Mode: GroupByModule}, "fmt", StdModule, StdModule},
+		{Grouping{Mode: GroupByModule}, "example.com/tool", "", "example.com/tool"},
+		{Grouping{Mode: GroupByDepth, Depth: 2}, "github.com/aws/aws-sdk-go-v2/service/s3", "", "github.com/aws"},
+		{Grouping{Mode: GroupByDepth, Depth: 5}, "net/http", StdModule, "net/http"},
+		{Grouping{Mode: GroupByOrg}, "github.com/aws/aws-sdk-go-v2/service/s3", "github.com/aws/aws-sdk-go-v2/service/s3", "github.com/aws"},
+		{Grouping{Mode: GroupByOrg}, "golang.org/x/net/http2", "golang.org/x/net", "golang.org/x"},
+		{Grouping{Mode: GroupByOrg}, "gopkg.in/yaml.v3", "gopkg.in/yaml.v3", "gopkg.in/yaml"},
+		{Grouping{Mode: GroupByOrg}, "k8s.io/client-go/rest", "k8s.io/client-go", "k8s.io"},
+		{Grouping{Mode: GroupByOrg}, "net/http", StdModule, StdModule},
+		{Grouping{Mode: GroupByNone}, "net/http", StdModule, "net/http"},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.Key(tt.pkg, tt.module); got != tt.want {
+			t.Errorf("%+v.Key(%q, %q) = %q, want %q", tt.mode, tt.pkg, tt.module, got, tt.want)
+		}
+	}
+}
+
+func TestGroupPackages(t *testing.T) {
+	report := &Report{Modules: []*ModuleReport{
+		{Path: "github.com/aws/aws-sdk-go-v2", Version: "v1.30.0", Packages: []*PackageReport{
+			{Path: "github.com/aws/aws-sdk-go-v2/aws", Size: 100},
+		}},
+		{Path: "github.com/aws/aws-sdk-go-v2/service/s3", Version: "v1.50.0", Packages: []*PackageReport{
+			{Path: "github.com/aws/aws-sdk-go-v2/service/s3", Size: 300},
+			{Path: "github.com/aws/aws-sdk-go-v2/service/s3/types", Size: 50},
+		}},
+		// 估算模式下没有包，按模块计入
+		{Path: "golang.org/x/net", Version: "v0.30.0", Size: 20},
+	}}
+
+	tests := []struct {
+		mode Grouping
+		want map[string]uint64
+	}{
+		{Grouping{Mode: GroupByModule}, map[string]uint64{
+			"github.com/aws/aws-sdk-go-v2/service/s3": 350,
+			"github.com/aws/aws-sdk-go-v2":            100,
+			"golang.org/x/net":                        20,
+		}},
+		{Grouping{Mode: GroupByOrg}, map[string]uint64{
+			"github.com/aws": 450,
+			"golang.org/x":   20,
+		}},
+	}
+	for _, tt := range tests {
+		got := make(map[string]uint64)
+		for _, e := range report.GroupPackages(tt.mode, nil) {
+			got[e.Name] = e.Size
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.mode.Mode, got, tt.want)
+		}
+	}
+
+	// 模块分组保留版本，组织分组中版本不一致时清空
+	for _, e := range report.GroupPackages(Grouping{Mode: GroupByOrg}, nil) {
+		if e.Name == "github.com/aws" && e.Version != "" {
+			t.Errorf("org group kept version %q", e.Version)
+		}
+	}
+}
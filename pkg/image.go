@@ -0,0 +1,194 @@
+package pkg
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// imageReader 按虚拟地址读取二进制文件中已初始化的数据（各节在文件中的内容）
+type imageReader struct {
+	order   binary.ByteOrder
+	ptrSize int
+	regions []*imageRegion
+	closer  io.Closer
+}
+
+type imageRegion struct {
+	addr, size uint64
+	load       func() ([]byte, error)
+	data       []byte
+}
+
+// openImageReader 打开 ELF、Mach-O 或 PE 文件（通用二进制文件中 arch 对应的切片），用完后需要调用 Close
+func openImageReader(path, arch string) (*imageReader, error) {
+	f, err := openBinary(path, arch)
+	if err != nil {
+		return nil, err
+	}
+	img := &imageReader{closer: f}
+
+	if ef, err := elf.NewFile(f); err == nil {
+		img.order = ef.ByteOrder
+		img.ptrSize = 4
+		if ef.Class == elf.ELFCLASS64 {
+			img.ptrSize = 8
+		}
+		for _, sec := range ef.Sections {
+			if sec.Type != elf.SHT_NOBITS && sec.Addr > 0 && sec.Flags&elf.SHF_ALLOC != 0 {
+				img.regions = append(img.regions, &imageRegion{addr: sec.Addr, size: sec.Size, load: sec.Data})
+			}
+		}
+		return img, nil
+	}
+	if mf, err := macho.NewFile(f); err == nil {
+		img.order = mf.ByteOrder
+		img.ptrSize = 4
+		if mf.Magic == macho.Magic64 {
+			img.ptrSize = 8
+		}
+		for _, sec := range mf.Sections {
+			if sec.Offset > 0 {
+				img.regions = append(img.regions, &imageRegion{addr: sec.Addr, size: sec.Size, load: sec.Data})
+			}
+		}
+		return img, nil
+	}
+	if pf, err := pe.NewFile(f); err == nil {
+		img.order = binary.LittleEndian
+		var imageBase uint64
+		switch oh := pf.OptionalHeader.(type) {
+		case *pe.OptionalHeader32:
+			img.ptrSize, imageBase = 4, uint64(oh.ImageBase)
+		case *pe.OptionalHeader64:
+			img.ptrSize, imageBase = 8, oh.ImageBase
+		}
+		for _, sec := range pf.Sections {
+			img.regions = append(img.regions, &imageRegion{
+				addr: imageBase + uint64(sec.VirtualAddress), size: uint64(sec.Size), load: sec.Data,
+			})
+		}
+		return img, nil
+	}
+
+	f.Close()
+	return nil, fmt.Errorf("%s is not an ELF, Mach-O or PE binary", path)
+}
+
+// relocatedImage 把可重定位的 64 位 ELF 目标文件装入内存：各个节依次放在合成的地址上，
+// 再应用其中的绝对地址重定位，这样指针可以像在链接好的文件中一样读取；
+// 返回的 bases 是每个节的起始地址，不装入内存的节为 0
+func relocatedImage(f *elf.File) (img *imageReader, bases []uint64, err error) {
+	if f.Class != elf.ELFCLASS64 {
+		return nil, nil, fmt.Errorf("relocatable %s files are not supported", f.Class)
+	}
+	var abs64 uint32
+	switch f.Machine {
+	case elf.EM_X86_64:
+		abs64 = uint32(elf.R_X86_64_64)
+	case elf.EM_AARCH64:
+		abs64 = uint32(elf.R_AARCH64_ABS64)
+	default:
+		return nil, nil, fmt.Errorf("relocations for %s are not supported", f.Machine)
+	}
+
+	img = &imageReader{order: f.ByteOrder, ptrSize: 8, closer: io.NopCloser(nil)}
+	bases = make([]uint64, len(f.Sections))
+	regions := make(map[int]*imageRegion)
+	next := uint64(0x10000)
+	for i, sec := range f.Sections {
+		if sec.Flags&elf.SHF_ALLOC == 0 || sec.Type == elf.SHT_NOBITS || sec.Size == 0 {
+			continue
+		}
+		data, err := sec.Data()
+		if err != nil {
+			return nil, nil, err
+		}
+		// 复制一份，重定位会改写其中的内容
+		r := &imageRegion{addr: next, size: uint64(len(data)), data: bytes.Clone(data)}
+		img.regions = append(img.regions, r)
+		regions[i], bases[i] = r, next
+		next += (r.size + 0xffff) &^ 0xffff
+		next += 0x10000
+	}
+
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, sec := range f.Sections {
+		target := regions[int(sec.Info)]
+		if sec.Type != elf.SHT_RELA || target == nil {
+			continue
+		}
+		data, err := sec.Data()
+		if err != nil {
+			return nil, nil, err
+		}
+		for off := 0; off+24 <= len(data); off += 24 {
+			where := f.ByteOrder.Uint64(data[off:])
+			info := f.ByteOrder.Uint64(data[off+8:])
+			addend := f.ByteOrder.Uint64(data[off+16:])
+			// Symbols 不含第 0 个空符号，下标要减一
+			index := info >> 32
+			if uint32(info) != abs64 || index == 0 || index > uint64(len(syms)) || target.size < 8 || where > target.size-8 {
+				continue
+			}
+			sym := syms[index-1]
+			if int(sym.Section) >= len(bases) || bases[sym.Section] == 0 {
+				continue
+			}
+			f.ByteOrder.PutUint64(target.data[where:], bases[sym.Section]+sym.Value+addend)
+		}
+	}
+	return img, bases, nil
+}
+
+func (img *imageReader) Close() error {
+	return img.closer.Close()
+}
+
+// read 读取从 addr 开始的 n 个字节
+func (img *imageReader) read(addr, n uint64) ([]byte, error) {
+	for _, r := range img.regions {
+		// 不计算 addr+n，避免文件中的任意值导致溢出
+		if addr < r.addr || n > r.size || addr-r.addr > r.size-n {
+			continue
+		}
+		if r.data == nil {
+			data, err := r.load()
+			if err != nil {
+				return nil, err
+			}
+			r.data = data
+		}
+		off := addr - r.addr
+		if n > uint64(len(r.data)) || off > uint64(len(r.data))-n {
+			break
+		}
+		return r.data[off : off+n], nil
+	}
+	return nil, fmt.Errorf("address %#x (+%d) is not in initialized data", addr, n)
+}
+
+// ptr 从 b 的开头解码一个指针大小的整数
+func (img *imageReader) ptr(b []byte) uint64 {
+	if img.ptrSize == 4 {
+		return uint64(img.order.Uint32(b))
+	}
+	return img.order.Uint64(b)
+}
+
+// readString 读取 Go 字符串头 (ptr, len) 指向的内容
+func (img *imageReader) readString(header []byte) (string, error) {
+	data, n := img.ptr(header), img.ptr(header[img.ptrSize:])
+	if n == 0 {
+		return "", nil
+	}
+	b, err := img.read(data, n)
+	return string(b), err
+}
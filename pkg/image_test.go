@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestImageReaderRead(t *testing.T) {
+	data := []byte("0123456789abcdef")
+	img := &imageReader{order: binary.LittleEndian, ptrSize: 8, regions: []*imageRegion{
+		{addr: 0x1000, size: uint64(len(data)), load: func() ([]byte, error) { return data, nil }},
+		// 节头声称的大小比实际内容大
+		{addr: 0x2000, size: 0x100, load: func() ([]byte, error) { return data, nil }},
+	}}
+
+	tests := []struct {
+		addr, n uint64
+		want    string
+		wantErr bool
+	}{
+		{addr: 0x1000, n: 4, want: "0123"},
+		{addr: 0x100c, n: 4, want: "cdef"},
+		{addr: 0x1010, n: 0, want: ""},
+		{addr: 0x100d, n: 4, wantErr: true},
+		{addr: 0xfff, n: 2, wantErr: true},
+		{addr: 0x1004, n: math.MaxUint64, wantErr: true},
+		{addr: math.MaxUint64, n: 2, wantErr: true},
+		{addr: 0x1004, n: math.MaxUint64 - 0x1000, wantErr: true},
+		{addr: 0x2008, n: 8, want: "89abcdef"},
+		{addr: 0x2010, n: 8, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := img.read(tt.addr, tt.n)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("read(%#x, %d) error = %v, wantErr %v", tt.addr, tt.n, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !bytes.Equal(got, []byte(tt.want)) {
+			t.Errorf("read(%#x, %d) = %q, want %q", tt.addr, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestImageReaderString(t *testing.T) {
+	img := &imageReader{order: binary.LittleEndian, ptrSize: 4, regions: []*imageRegion{
+		{addr: 0x10, size: 5, load: func() ([]byte, error) { return []byte("hello"), nil }},
+	}}
+	header := binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, 0x11), 3)
+	if s, err := img.readString(header); err != nil || s != "ell" {
+		t.Errorf("readString = %q, %v", s, err)
+	}
+	header = binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, 0x11), 0xffffffff)
+	if _, err := img.readString(header); err == nil {
+		t.Error("readString with an out of range length did not fail")
+	}
+}
@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"debug/dwarf"
+	"sort"
+)
+
+// 大小视图
+const (
+	ViewPhysical = "physical" // 代码计入它所在的函数
+	ViewLogical  = "logical"  // 内联进来的代码计入被内联函数所在的包
+)
+
+// Views 是 --view 可以选择的视图
+var Views = []string{ViewPhysical, ViewLogical}
+
+// inlineSymbols 按 DWARF 中的 TagInlinedSubroutine 把内联进来的代码从调用方的函数符号中拆出来，
+// 计入被内联函数所在的包；返回调整了大小的符号和拆出的字节数
+//
+// 内联可以嵌套，每段代码只计入最内层的被内联函数；被内联函数与调用方同包时不拆分
+func inlineSymbols(d *dwarf.Data, symbols []Symbol) ([]Symbol, uint64) {
+	byAddr := make(map[uint64]*Symbol)
+	for i := range symbols {
+		if sym := &symbols[i]; sym.Kind == KindFunc || sym.Kind == KindClosure {
+			byAddr[sym.Address] = sym
+		}
+	}
+
+	w := &inlineWalker{d: d, r: d.Reader(), names: make(map[dwarf.Offset]string), moved: make(map[string]uint64)}
+	var inlined uint64
+	for {
+		entry, err := w.r.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagSubprogram || !entry.Children {
+			continue
+		}
+		ranges, _ := d.Ranges(entry)
+		var fn *Symbol
+		if len(ranges) > 0 {
+			fn = byAddr[ranges[0][0]]
+		}
+		if fn == nil {
+			w.r.SkipChildren()
+			continue
+		}
+		w.fn = fn
+		w.children()
+		if len(w.pending) > 0 && fn.Span == 0 {
+			fn.Span = fn.Size // 源文件归属仍按整个函数的地址范围
+		}
+		for callee, size := range w.pending {
+			size = min(size, fn.Size)
+			fn.Size -= size
+			w.moved[callee] += size
+			inlined += size
+		}
+		clear(w.pending)
+	}
+
+	callees := make([]string, 0, len(w.moved))
+	for callee := range w.moved {
+		callees = append(callees, callee)
+	}
+	sort.Strings(callees)
+	for _, callee := range callees {
+		symbols = append(symbols, Symbol{
+			Name:    callee + " (inlined)",
+			Size:    w.moved[callee],
+			Package: extractPackageFromSymbol(callee),
+			Section: ".text",
+			Generic: genericOrigin(callee, KindInline),
+			Kind:    KindInline,
+		})
+	}
+	return symbols, inlined
+}
+
+// inlineWalker 遍历一个函数的 DWARF 子节点，累计其中属于其他包的内联代码
+type inlineWalker struct {
+	d       *dwarf.Data
+	r       *dwarf.Reader
+	names   map[dwarf.Offset]string // 抽象函数的偏移 -> 函数名
+	fn      *Symbol
+	pending map[string]uint64 // 当前函数中每个被内联函数独占的字节数
+	moved   map[string]uint64 // 所有函数累计
+}
+
+// children 读完当前节点的所有子节点，返回其中内联代码的总字节数
+func (w *inlineWalker) children() uint64 {
+	if w.pending == nil {
+		w.pending = make(map[string]uint64)
+	}
+	var total uint64
+	for {
+		entry, err := w.r.Next()
+		if err != nil || entry == nil || entry.Tag == 0 {
+			return total
+		}
+		if entry.Tag != dwarf.TagInlinedSubroutine {
+			if entry.Children {
+				// 词法块中也可能有内联代码
+				total += w.children()
+			}
+			continue
+		}
+		var size uint64
+		ranges, _ := w.d.Ranges(entry)
+		for _, r := range ranges {
+			size += r[1] - r[0]
+		}
+		own := size
+		if entry.Children {
+			own -= min(own, w.children())
+		}
+		total += size
+		callee := w.name(entry)
+		if pkg := extractPackageFromSymbol(callee); pkg != "" && pkg != w.fn.Package && own > 0 {
+			w.pending[callee] += own
+		}
+	}
+}
+
+// name 返回内联实例的抽象函数名
+func (w *inlineWalker) name(entry *dwarf.Entry) string {
+	off, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+	if !ok {
+		return ""
+	}
+	if name, ok := w.names[off]; ok {
+		return name
+	}
+	r := w.d.Reader()
+	r.Seek(off)
+	name := ""
+	if origin, err := r.Next(); err == nil && origin != nil {
+		name, _ = origin.Val(dwarf.AttrName).(string)
+	}
+	w.names[off] = name
+	return name
+}
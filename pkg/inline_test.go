@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+const inlineMain = `package main
+
+import (
+	"fmt"
+	"os"
+
+	"example.com/app/util"
+)
+
+func main() {
+	n := len(os.Args)
+	fmt.Println(util.Mix(n, 3), util.Mix(n, 5), util.Mix(n, 7))
+}
+`
+
+const inlineUtil = `package util
+
+func Mix(a, b int) int { return a*b ^ (a + b) }
+`
+
+func TestLogicalView(t *testing.T) {
+	files := map[string]string{"main.go": inlineMain, "util/util.go": inlineUtil}
+	binary := buildTestBinary(t, files, nil)
+
+	physical, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logical, err := (&GoWeight{View: ViewLogical}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if physical.View != "" || logical.View != ViewLogical {
+		t.Errorf("views = %q, %q", physical.View, logical.View)
+	}
+	if logical.Totals.Inlined == 0 {
+		t.Fatal("nothing was inlined")
+	}
+	// 内联代码只是换了归属，总数不变
+	if logical.Totals.Attributed != physical.Totals.Attributed {
+		t.Errorf("attributed %d bytes in the logical view, %d in the physical view", logical.Totals.Attributed, physical.Totals.Attributed)
+	}
+
+	sizes := func(r *Report) map[string]*PackageReport {
+		m := make(map[string]*PackageReport)
+		for _, p := range r.Packages() {
+			m[p.Path] = p
+		}
+		return m
+	}
+	before, after := sizes(physical), sizes(logical)
+	if before["example.com/app/util"] != nil {
+		t.Errorf("util has %d bytes in the physical view, Mix should be inlined", before["example.com/app/util"].Size)
+	}
+	util := after["example.com/app/util"]
+	if util == nil || util.Size == 0 {
+		t.Fatal("util has no bytes in the logical view")
+	}
+	found := false
+	for _, sym := range util.Symbols {
+		if sym.Name == "example.com/app/util.Mix (inlined)" && sym.Kind == KindInline && sym.Size == util.Size {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("util symbols = %+v", util.Symbols)
+	}
+	if app := after["example.com/app"]; app == nil || app.Size >= before["example.com/app"].Size {
+		t.Error("the main package did not lose the inlined bytes")
+	}
+
+	// 源文件归属按函数的地址范围，与视图无关
+	g := &GoWeight{}
+	physicalFiles, err := g.AnalyzeSources(physical, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logicalFiles, err := g.AnalyzeSources(logical, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileSizes := func(r *SourceReport) map[string]uint64 {
+		m := map[string]uint64{"": r.Unattributed}
+		for _, f := range r.Files {
+			m[f.Path] = f.Size
+		}
+		return m
+	}
+	if got, want := fileSizes(logicalFiles), fileSizes(physicalFiles); !reflect.DeepEqual(got, want) {
+		t.Errorf("files in the logical view = %v, in the physical view = %v", got, want)
+	}
+
+	// 没有 DWARF 时保持物理视图
+	stripped := buildTestBinary(t, files, nil, "-ldflags=-w")
+	report, err := (&GoWeight{View: ViewLogical}).AnalyzeBinary(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.View != "" || report.Totals.Inlined != 0 {
+		t.Errorf("view = %q, inlined = %d without DWARF", report.View, report.Totals.Inlined)
+	}
+}
@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"debug/dwarf"
+	"sort"
+	"strings"
+)
+
+// dwarfGoRuntimeType 是 Go 链接器写入 DWARF 类型条目的 DW_AT_go_runtime_type 属性，
+// 值是该类型的运行时类型描述符的地址（较新的版本中是相对 runtime.types 的偏移）
+const dwarfGoRuntimeType dwarf.Attr = 0x2904
+
+// typePackage 返回定义类型的包，name 是去掉 type: 前缀的类型名
+// 指针、切片、数组和通道归属元素类型，map 归属值类型（值类型没有包时取键类型）；
+// 内置类型以及匿名的函数、结构体和接口类型不属于任何包
+func typePackage(name string) string {
+	// type:.eq.pkg.T、type:.hash.pkg.T 是为类型生成的比较和哈希函数
+	for _, prefix := range []string{".eq.", ".hash."} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			name = rest
+		}
+	}
+	// noalg.* 是编译器为 map 内部结构生成的不需要比较函数的类型，如 noalg.map.group[K]V
+	name = strings.TrimPrefix(name, "noalg.")
+	for _, prefix := range []string{"map.group[", "map.bucket["} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			name = "map[" + rest
+		}
+	}
+	for {
+		switch {
+		case strings.HasPrefix(name, "*"):
+			name = name[1:]
+		case strings.HasPrefix(name, "[]"):
+			name = name[2:]
+		case strings.HasPrefix(name, "chan "), strings.HasPrefix(name, "<-chan "), strings.HasPrefix(name, "chan<- "):
+			_, name, _ = strings.Cut(name, " ")
+		case strings.HasPrefix(name, "map["):
+			key, value := splitMapType(name)
+			if p := typePackage(value); p != "" {
+				return p
+			}
+			return typePackage(key)
+		case strings.HasPrefix(name, "["):
+			_, name, _ = strings.Cut(name, "]")
+		default:
+			if name == "" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "func(") ||
+				strings.HasPrefix(name, "struct {") || strings.HasPrefix(name, "interface {") {
+				return ""
+			}
+			return extractPackageFromSymbol(name)
+		}
+	}
+}
+
+// splitMapType 把 map[K]V 拆成 K 和 V，K 中可能嵌套方括号
+func splitMapType(name string) (key, value string) {
+	depth := 0
+	for i := len("map"); i < len(name); i++ {
+		switch name[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return name[len("map["):i], name[i+1:]
+			}
+		}
+	}
+	return "", ""
+}
+
+// hasSizedTypes 判断符号表中是否已经有带大小的类型描述符
+func hasSizedTypes(symbols []Symbol) bool {
+	for _, sym := range symbols {
+		if strings.HasPrefix(sym.Name, "type:") && !strings.HasPrefix(sym.Name, "type:.") && sym.Size > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// typesRegion 返回存放类型描述符的地址范围：优先使用 runtime.types 和 runtime.etypes 符号，
+// 其次是独立的 .go.type 节
+func (image *binaryImage) typesRegion() (start, end uint64, section string, ok bool) {
+	for _, sym := range image.Symbols {
+		switch sym.Name {
+		case "runtime.types":
+			start, section = sym.Address, sym.Section
+		case "runtime.etypes":
+			end = sym.Address
+		}
+	}
+	if start > 0 && end > start {
+		return start, end, section, true
+	}
+	for _, sec := range image.Sections {
+		if sec.Name == ".go.type" && sec.Addr > 0 {
+			return sec.Addr, sec.Addr + sec.Size, sec.Name, true
+		}
+	}
+	return 0, 0, "", false
+}
+
+// typeSymbols 根据 DWARF 类型条目的运行时类型地址合成类型描述符符号
+// 链接器按顺序排列类型描述符，每个描述符的大小取到下一个描述符（或区域末尾）为止
+func typeSymbols(d *dwarf.Data, start, end uint64, section string) []Symbol {
+	names := make(map[uint64]string)
+	r := d.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		addr, ok := entry.Val(dwarfGoRuntimeType).(uint64)
+		if !ok {
+			continue
+		}
+		if addr < start {
+			addr += start // 相对 runtime.types 的偏移
+		}
+		if addr >= end {
+			continue
+		}
+		if _, seen := names[addr]; !seen {
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			names[addr] = name
+		}
+	}
+
+	addrs := make([]uint64, 0, len(names))
+	for addr := range names {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	var symbols []Symbol
+	for i, addr := range addrs {
+		next := end
+		if i+1 < len(addrs) {
+			next = addrs[i+1]
+		}
+		name := "type:" + names[addr]
+		if pkg := extractPackageFromSymbol(name); pkg != "" {
+			symbols = append(symbols, Symbol{Name: name, Size: next - addr, Address: addr, Package: pkg, Section: section})
+		}
+	}
+	return symbols
+}
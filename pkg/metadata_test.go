@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypePackage(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"main.T", "main"},
+		{"*net/http.Request", "net/http"},
+		{"[]*github.com/spf13/cobra.Command", "github.com/spf13/cobra"},
+		{"[4]os.File", "os"},
+		{"chan time.Time", "time"},
+		{"<-chan time.Time", "time"},
+		{"map[string]*os.File", "os"},
+		{"map[os.Signal]bool", "os"},
+		{"map[[2]string]int", ""},
+		{"noalg.map.group[string]*os.File", "os"},
+		{".eq.net/url.URL", "net/url"},
+		{"int", ""},
+		{"*uint8", ""},
+		{"func(string) error", ""},
+		{"struct { F uintptr }", ""},
+		{"interface { Error() string }", ""},
+	}
+	for _, tt := range tests {
+		if got := typePackage(tt.name); got != tt.want {
+			t.Errorf("typePackage(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStringDataSize(t *testing.T) {
+	tests := []struct {
+		start, end uint64
+		addrs      []uint64
+		want       uint64
+	}{
+		{0x1000, 0x2000, nil, 0x1000},
+		{0x1000, 0x2000, []uint64{0x1000, 0x1800, 0x1400}, 0x400},
+		{0x1000, 0x2000, []uint64{0x800, 0x1000}, 0x1000},
+		{0x1000, 0x2000, []uint64{0x3000}, 0x1000},
+		{0x2000, 0x1000, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := stringDataSize(tt.start, tt.end, tt.addrs); got != tt.want {
+			t.Errorf("stringDataSize(%#x, %#x, %#x) = %#x, want %#x", tt.start, tt.end, tt.addrs, got, tt.want)
+		}
+	}
+}
+
+const metadataMain = `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type Greeter struct{ Name string }
+
+func (g *Greeter) String() string { return "hello, " + g.Name }
+
+func main() {
+	var s fmt.Stringer = &Greeter{Name: os.Args[0]}
+	fmt.Println(s, "a string literal that lands in go:string")
+}
+`
+
+func TestTypeMetadata(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{"main.go": metadataMain}, nil)
+
+	report, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var app *PackageReport
+	for _, p := range report.Packages() {
+		if p.Path == "example.com/app" {
+			app = p
+		}
+	}
+	if app == nil {
+		t.Fatal("main package not found")
+	}
+	if app.Metadata == 0 || app.Metadata > app.Size {
+		t.Errorf("main package metadata = %d, size %d", app.Metadata, app.Size)
+	}
+	var types int
+	for _, sym := range app.Symbols {
+		if sym.Kind == KindType && strings.Contains(sym.Name, "Greeter") {
+			types++
+		}
+	}
+	if types == 0 {
+		t.Error("main package has no Greeter type descriptors")
+	}
+
+	totals := report.Totals
+	if totals.Strings == 0 || totals.Strings > totals.Unattributed {
+		t.Errorf("strings = %d, unattributed %d", totals.Strings, totals.Unattributed)
+	}
+}
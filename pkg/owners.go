@@ -0,0 +1,226 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// UnownedOwner 是没有匹配任何所有权规则的主模块包所在的分组
+const UnownedOwner = "(unowned)"
+
+// Owners 是 CODEOWNERS 风格的所有权文件：每行一个包路径模式，后面跟一个或多个团队名
+//
+//	# 注释
+//	/internal/billing/...   @payments
+//	/cmd/...                @platform @release
+//	github.com/acme/shared  @platform
+//
+// 以 / 开头的模式相对于主模块，其余为完整的包路径；通配符规则与规则文件相同，单独的 / 或 * 匹配所有包。
+// 与 CODEOWNERS 一样，最后一个匹配的行生效；只有模式没有团队名的行表示不属于任何团队。
+type Owners struct {
+	rules []ownerRule
+}
+
+type ownerRule struct {
+	pattern  string // 去掉首尾 / 的模式
+	relative bool   // 相对于主模块
+	re       *regexp.Regexp
+	owners   []string
+}
+
+// LoadOwners 读取并解析所有权文件
+func LoadOwners(path string) (*Owners, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	owners := &Owners{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		rule := ownerRule{
+			pattern:  strings.Trim(fields[0], "/"),
+			relative: strings.HasPrefix(fields[0], "/"),
+			owners:   fields[1:],
+		}
+		expr := globToRegexp(rule.pattern)
+		if rule.pattern == "" || rule.pattern == "*" {
+			expr = ".*" // / 和 * 匹配所有包
+		}
+		if rule.re, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("%s:%d: pattern %q: %w", path, line, fields[0], err)
+		}
+		owners.rules = append(owners.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
+
+// Owner 返回包的所有者，mainModule 是主模块路径，用于匹配相对模式
+func (o *Owners) Owner(pkgPath, mainModule string) []string {
+	rel, inMain := "", false
+	if pkgPath == mainModule {
+		inMain = true
+	} else if r, ok := strings.CutPrefix(pkgPath, mainModule+"/"); ok {
+		rel, inMain = r, true
+	}
+
+	var owners []string
+	for _, rule := range o.rules {
+		switch {
+		case rule.relative && inMain && rule.re.MatchString(rel):
+			owners = rule.owners
+		case !rule.relative && rule.re.MatchString(pkgPath):
+			owners = rule.owners
+		}
+	}
+	return owners
+}
+
+// OwnerReport 是一个团队拥有的链接大小
+type OwnerReport struct {
+	Owner     string   `json:"owner"`
+	Size      uint64   `json:"size"`
+	SizeHuman string   `json:"size_human"`
+	Packages  []string `json:"packages"`
+	// SoleModules 是只被该团队的包导入的第三方模块
+	SoleModules []*LinkedModule `json:"sole_modules,omitempty"`
+}
+
+// LinkedModule 是一个链接进二进制文件的模块及其大小
+type LinkedModule struct {
+	Path      string `json:"path"`
+	Version   string `json:"version,omitempty"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+}
+
+func newLinkedModule(m *ModuleReport) *LinkedModule {
+	return &LinkedModule{Path: m.Path, Version: m.Version, Size: m.Size, SizeHuman: humanize.Bytes(m.Size)}
+}
+
+// sortLinkedModules 按大小降序排序
+func sortLinkedModules(modules []*LinkedModule) {
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Size != modules[j].Size {
+			return modules[i].Size > modules[j].Size
+		}
+		return modules[i].Path < modules[j].Path
+	})
+}
+
+// AttributeOwners 按所有者汇总主模块中各包的链接大小
+// 有多个所有者的包会计入每个所有者。graph 不为空时，还找出只被某个团队的包
+// （不经过主模块中其他包）导入的第三方模块
+func (o *Owners) AttributeOwners(report *Report, graph *ImportGraph) []*OwnerReport {
+	mainModule := report.Build.MainModule.Path
+	byOwner := make(map[string]*OwnerReport)
+	get := func(owner string) *OwnerReport {
+		if byOwner[owner] == nil {
+			byOwner[owner] = &OwnerReport{Owner: owner}
+		}
+		return byOwner[owner]
+	}
+	ownersOf := func(pkgPath string) []string {
+		if owners := o.Owner(pkgPath, mainModule); len(owners) > 0 {
+			return owners
+		}
+		return []string{UnownedOwner}
+	}
+
+	modules := make(map[string]*ModuleReport)
+	for _, m := range report.Modules {
+		modules[m.Path] = m
+		if !m.Main {
+			continue
+		}
+		for _, p := range m.Packages {
+			for _, owner := range ownersOf(p.Path) {
+				r := get(owner)
+				r.Size += p.Size
+				r.Packages = append(r.Packages, p.Path)
+			}
+		}
+	}
+
+	if graph != nil {
+		importers := make(map[string]map[string]bool)
+		for path, node := range graph.Nodes {
+			if node.Module != mainModule {
+				continue
+			}
+			owners := ownersOf(path)
+			for module := range graph.externalModules(path, mainModule) {
+				if importers[module] == nil {
+					importers[module] = make(map[string]bool)
+				}
+				for _, owner := range owners {
+					importers[module][owner] = true
+				}
+			}
+		}
+		for module, owners := range importers {
+			if len(owners) != 1 {
+				continue
+			}
+			m, ok := modules[module]
+			if !ok {
+				continue
+			}
+			for owner := range owners {
+				r := get(owner)
+				r.SoleModules = append(r.SoleModules, newLinkedModule(m))
+			}
+		}
+	}
+
+	result := make([]*OwnerReport, 0, len(byOwner))
+	for _, r := range byOwner {
+		r.SizeHuman = humanize.Bytes(r.Size)
+		sort.Strings(r.Packages)
+		sortLinkedModules(r.SoleModules)
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Owner < result[j].Owner
+	})
+	return result
+}
+
+// externalModules 返回从 from 出发、不经过主模块中其他包就能到达的第三方模块
+func (ig *ImportGraph) externalModules(from, mainModule string) map[string]bool {
+	modules := make(map[string]bool)
+	seen := map[string]bool{from: true}
+	stack := append([]string(nil), ig.Nodes[from].Imports...)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node, ok := ig.Nodes[n]
+		if seen[n] || !ok || node.Module == mainModule {
+			continue
+		}
+		seen[n] = true
+		if !node.Standard && node.Module != "" {
+			modules[node.Module] = true
+		}
+		stack = append(stack, node.Imports...)
+	}
+	return modules
+}
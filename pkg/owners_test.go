@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadTestOwners(t *testing.T, content string) *Owners {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "OWNERS")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	owners, err := LoadOwners(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return owners
+}
+
+const testOwners = `# 所有权
+*                        @core
+/internal/billing/...    @payments
+/cmd/...                 @platform @release
+/internal/billing/legacy
+github.com/acme/shared   @platform
+`
+
+func TestOwner(t *testing.T) {
+	owners := loadTestOwners(t, testOwners)
+	tests := []struct {
+		pkg  string
+		want []string
+	}{
+		{"example.com/app", []string{"@core"}},
+		{"example.com/app/internal/billing", []string{"@payments"}},
+		{"example.com/app/internal/billing/stripe", []string{"@payments"}},
+		{"example.com/app/cmd/server", []string{"@platform", "@release"}},
+		// 后面没有团队名的行取消前面的匹配
+		{"example.com/app/internal/billing/legacy", []string{}},
+		{"github.com/acme/shared/log", []string{"@platform"}},
+		// 相对模式只匹配主模块
+		{"github.com/other/internal/billing", []string{"@core"}},
+	}
+	for _, tt := range tests {
+		got := owners.Owner(tt.pkg, "example.com/app")
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owner(%q) = %v, want %v", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestAttributeOwners(t *testing.T) {
+	owners := loadTestOwners(t, testOwners)
+	report := &Report{
+		Build: BuildMetadata{MainModule: ModuleVersion{Path: "example.com/app"}},
+		Modules: []*ModuleReport{
+			{Path: "example.com/app", Main: true, Packages: []*PackageReport{
+				{Path: "example.com/app/cmd/server", Size: 100},
+				{Path: "example.com/app/internal/billing", Size: 200},
+				{Path: "example.com/app/internal/billing/legacy", Size: 50},
+			}},
+			{Path: "github.com/stripe/stripe-go", Size: 1000},
+			{Path: "github.com/spf13/cobra", Size: 300},
+			{Path: "github.com/shared/log", Size: 10},
+		},
+	}
+	graph := &ImportGraph{Nodes: map[string]*PackageNode{
+		"example.com/app/cmd/server":              {Module: "example.com/app", Imports: []string{"example.com/app/internal/billing", "github.com/spf13/cobra", "github.com/shared/log"}},
+		"example.com/app/internal/billing":        {Module: "example.com/app", Imports: []string{"github.com/stripe/stripe-go", "github.com/shared/log"}},
+		"example.com/app/internal/billing/legacy": {Module: "example.com/app"},
+		"github.com/stripe/stripe-go":             {Module: "github.com/stripe/stripe-go"},
+		"github.com/spf13/cobra":                  {Module: "github.com/spf13/cobra", Imports: []string{"fmt"}},
+		"github.com/shared/log":                   {Module: "github.com/shared/log"},
+		"fmt":                                     {Standard: true},
+	}}
+
+	type owned struct {
+		size        uint64
+		soleModules []string
+	}
+	// cmd/server 属于两个团队，cobra 不算任何一个团队独有
+	want := map[string]owned{
+		"@payments":  {200, []string{"github.com/stripe/stripe-go"}},
+		"@platform":  {100, nil},
+		"@release":   {100, nil},
+		UnownedOwner: {50, nil},
+	}
+	result := owners.AttributeOwners(report, graph)
+	got := make(map[string]owned)
+	for _, r := range result {
+		var sole []string
+		for _, m := range r.SoleModules {
+			sole = append(sole, m.Path)
+		}
+		got[r.Owner] = owned{r.Size, sole}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AttributeOwners = %+v, want %+v", got, want)
+	}
+	if result[0].Owner != "@payments" {
+		t.Errorf("largest owner = %s, want @payments", result[0].Owner)
+	}
+}
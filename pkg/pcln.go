@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// pcTable 按函数解码 Go 1.18 起的 pclntab 中的 pc-value 表
+// debug/gosym 只能逐个地址查询，每次都要从函数入口解码，对整个代码段来说太慢
+type pcTable struct {
+	order     binary.ByteOrder
+	quantum   uint64
+	textStart uint64
+	cutab     []byte
+	filetab   []byte
+	pctab     []byte
+	funcdata  []byte
+	nfunc     int
+}
+
+// pcRange 是 pc-value 表中的一段：[start, end) 中的指令取值 val
+type pcRange struct {
+	start, end uint64
+	val        int32
+}
+
+// newPCTable 解析 pclntab 的头部，textStart 是代码段的起始地址（与 parsePclntab 相同）
+func newPCTable(data []byte, textStart uint64) (*pcTable, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("pclntab too short")
+	}
+	t := &pcTable{textStart: textStart, quantum: uint64(data[6])}
+	ptrSize := int(data[7])
+	switch {
+	case binary.LittleEndian.Uint32(data) == 0xfffffff0 || binary.LittleEndian.Uint32(data) == 0xfffffff1:
+		t.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(data) == 0xfffffff0 || binary.BigEndian.Uint32(data) == 0xfffffff1:
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("pclntab before Go 1.18 is not supported")
+	}
+	if ptrSize != 4 && ptrSize != 8 || len(data) < 8+8*ptrSize {
+		return nil, fmt.Errorf("malformed pclntab header")
+	}
+	word := func(i int) uint64 {
+		b := data[8+i*ptrSize:]
+		if ptrSize == 4 {
+			return uint64(t.order.Uint32(b))
+		}
+		return t.order.Uint64(b)
+	}
+	section := func(i int) ([]byte, error) {
+		off := word(i)
+		if off > uint64(len(data)) {
+			return nil, fmt.Errorf("pclntab table %d out of range", i)
+		}
+		return data[off:], nil
+	}
+	// 头部依次是 nfunctab、nfiletab、textStart、funcnametab、cutab、filetab、pctab、funcdata（即 functab）
+	nfunc := word(0)
+	var err error
+	for _, s := range []struct {
+		i   int
+		dst *[]byte
+	}{{4, &t.cutab}, {5, &t.filetab}, {6, &t.pctab}, {7, &t.funcdata}} {
+		if *s.dst, err = section(s.i); err != nil {
+			return nil, err
+		}
+	}
+	// 先按字节数限制函数个数，避免损坏的数据让乘法溢出
+	if nfunc > uint64(len(t.funcdata))/8 || uint64(len(t.funcdata)) < (2*nfunc+1)*4 {
+		return nil, fmt.Errorf("pclntab function table out of range")
+	}
+	t.nfunc = int(nfunc)
+	return t, nil
+}
+
+// funcs 返回每个函数入口地址对应的 _func 结构
+func (t *pcTable) funcs() map[uint64][]byte {
+	funcs := make(map[uint64][]byte, t.nfunc)
+	for i := 0; i < t.nfunc; i++ {
+		// functab 的每一项是 (入口相对 textStart 的偏移, _func 相对 funcdata 的偏移)
+		funcOff := t.order.Uint32(t.funcdata[8*i+4:])
+		if uint64(funcOff)+40 > uint64(len(t.funcdata)) {
+			continue
+		}
+		fn := t.funcdata[funcOff:]
+		funcs[t.textStart+uint64(t.order.Uint32(fn))] = fn
+	}
+	return funcs
+}
+
+// _func 结构中用到的字段偏移
+const (
+	funcPcfileOff   = 20
+	funcPclnOff     = 24
+	funcCuOffsetOff = 32
+)
+
+// values 解码 _func 中偏移为 field 的 pc-value 表，entry 是函数入口地址
+func (t *pcTable) values(fn []byte, field int, entry uint64) []pcRange {
+	off := t.order.Uint32(fn[field:])
+	if off == 0 || uint64(off) >= uint64(len(t.pctab)) {
+		return nil
+	}
+	p := t.pctab[off:]
+	var ranges []pcRange
+	pc, val := entry, int32(-1)
+	for first := true; len(p) > 0; first = false {
+		uvdelta, n := binary.Uvarint(p)
+		if n <= 0 || (uvdelta == 0 && !first) {
+			break
+		}
+		p = p[n:]
+		val += int32(-(uint32(uvdelta) & 1) ^ (uint32(uvdelta) >> 1))
+		pcdelta, n := binary.Uvarint(p)
+		if n <= 0 {
+			break
+		}
+		p = p[n:]
+		end := pc + pcdelta*t.quantum
+		ranges = append(ranges, pcRange{start: pc, end: end, val: val})
+		pc = end
+	}
+	return ranges
+}
+
+// file 返回函数所在编译单元的第 fileno 个文件名
+func (t *pcTable) file(fn []byte, fileno int32) string {
+	if fileno < 0 {
+		return ""
+	}
+	i := uint64(t.order.Uint32(fn[funcCuOffsetOff:])) + uint64(fileno)
+	if (i+1)*4 > uint64(len(t.cutab)) {
+		return ""
+	}
+	off := t.order.Uint32(t.cutab[i*4:])
+	if uint64(off) >= uint64(len(t.filetab)) {
+		return ""
+	}
+	name := t.filetab[off:]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return string(name)
+}
+
+// fileLines 按地址顺序回调函数中每段指令所在的源文件和行号，只访问 [entry, end) 之内的部分
+// 内联进来的代码记在被内联函数的文件和行号上
+func (t *pcTable) fileLines(fn []byte, entry, end uint64, visit func(start, end uint64, file string, line int)) {
+	files := t.values(fn, funcPcfileOff, entry)
+	lines := t.values(fn, funcPclnOff, entry)
+	names := make(map[int32]string)
+	for i, j := 0, 0; i < len(files) && j < len(lines); {
+		f, l := files[i], lines[j]
+		start, stop := max(f.start, l.start), min(f.end, l.end, end)
+		if start < stop {
+			name, ok := names[f.val]
+			if !ok {
+				name = t.file(fn, f.val)
+				names[f.val] = name
+			}
+			visit(start, stop, name, int(l.val))
+		}
+		if stop >= end {
+			break
+		}
+		if f.end <= l.end {
+			i++
+		} else {
+			j++
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package pkg
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ReportSchemaVersion 是 JSON 报告的结构版本，不兼容的改动必须递增它
+// 对应的 JSON Schema 见 schema/report.v1.schema.json
+const ReportSchemaVersion = 1
+
+// StdModule 是标准库包所属的伪模块名（与 GOROOT/src/go.mod 中的模块名一致）
+const StdModule = "std"
+
+// Report 是一次二进制文件分析的完整结果
+type Report struct {
+	SchemaVersion int           `json:"schema_version"`
+	Binary        BinaryInfo    `json:"binary"`
+	Build         BuildMetadata `json:"build"`
+	Source        string        `json:"source"`
+	// View 是 logical 时内联代码计入了被内联函数的包，否则为空（physical）
+	View    string          `json:"view,omitempty"`
+	Totals  ReportTotals    `json:"totals"`
+	Modules []*ModuleReport `json:"modules"`
+	// Embeds 是从 embed.FS 文件表中读出的嵌入文件，它们不计入模块大小
+	Embeds []*EmbedReport `json:"embeds,omitempty"`
+	// DataSegments 是 WebAssembly 模块的数据段，它们没有符号，不计入模块大小
+	DataSegments *DataSegments `json:"data_segments,omitempty"`
+	// Slices 是通用二进制文件每个切片的报告，只在 UniversalReport 合成的报告中出现
+	Slices []*Report `json:"slices,omitempty"`
+}
+
+// BinaryInfo 描述被分析的二进制文件
+type BinaryInfo struct {
+	Path string `json:"path"`
+	// Size 是文件大小，通用二进制文件中是所分析的切片的大小
+	Size   uint64 `json:"size"`
+	Format string `json:"format"`
+	// Arch 是所分析的通用二进制文件切片的架构，Arches 是文件中的全部架构；普通二进制文件两者都为空
+	Arch   string   `json:"arch,omitempty"`
+	Arches []string `json:"arches,omitempty"`
+	// DebugFile 是提供符号表和 DWARF 的独立调试文件（--debug-file 或在 --debug-dir 中找到的）
+	DebugFile string `json:"debug_file,omitempty"`
+	// Libraries 是二进制文件运行时依赖的共享库（ELF DT_NEEDED、Mach-O LC_LOAD_DYLIB、PE 导入表）
+	Libraries []string `json:"libraries,omitempty"`
+}
+
+// BuildMetadata 是从 buildinfo 中读出的构建信息
+type BuildMetadata struct {
+	GoVersion  string         `json:"go_version"`
+	GOOS       string         `json:"goos,omitempty"`
+	GOARCH     string         `json:"goarch,omitempty"`
+	Path       string         `json:"path"`
+	MainModule ModuleVersion  `json:"main_module"`
+	VCS        *VCSInfo       `json:"vcs,omitempty"`
+	Settings   []BuildSetting `json:"settings,omitempty"`
+}
+
+// ModuleVersion 是模块路径和版本
+type ModuleVersion struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+// VCSInfo 是构建时记录的版本控制信息
+type VCSInfo struct {
+	System   string `json:"system"`
+	Revision string `json:"revision,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Modified bool   `json:"modified"`
+}
+
+// BuildSetting 是 buildinfo 中的一项构建设置
+type BuildSetting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ReportTotals 汇总整个二进制文件的大小归属情况
+type ReportTotals struct {
+	BinarySize   uint64  `json:"binary_size"`
+	Attributed   uint64  `json:"attributed"`
+	Unattributed uint64  `json:"unattributed"`
+	Coverage     float64 `json:"coverage"`
+	Modules      int     `json:"modules"`
+	Packages     int     `json:"packages"`
+	Symbols      int     `json:"symbols"`
+	Embedded     uint64  `json:"embedded"`
+	// ExportData 是归档文件中 __.PKGDEF 导出数据的大小，只在分析 .a 文件时出现
+	ExportData uint64 `json:"export_data,omitempty"`
+	// Memory 是所有包的静态内存之和（仅 ELF）
+	Memory uint64 `json:"memory,omitempty"`
+	// Inlined 是逻辑视图中从调用方移到被内联函数所在包的字节数
+	Inlined uint64 `json:"inlined,omitempty"`
+	// Strings 是字符串字面量的字节数，它们在包之间共享，计入 Unattributed（仅 ELF 和 Mach-O）
+	Strings uint64 `json:"strings,omitempty"`
+}
+
+// ModuleReport 是一个模块及其链接进二进制文件的包
+type ModuleReport struct {
+	Path    string         `json:"path"`
+	Version string         `json:"version,omitempty"`
+	Replace *ModuleVersion `json:"replace,omitempty"`
+	Main    bool           `json:"main,omitempty"`
+	// Origin 是来源分类，只在调用 ClassifyOrigins 之后出现
+	Origin    string           `json:"origin,omitempty"`
+	Size      uint64           `json:"size"`
+	SizeHuman string           `json:"size_human"`
+	Metadata  uint64           `json:"metadata"`
+	Memory    uint64           `json:"memory,omitempty"`
+	Packages  []*PackageReport `json:"packages"`
+}
+
+// PackageReport 是一个包及其链接进二进制文件的符号
+type PackageReport struct {
+	Path      string `json:"path"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+	// Metadata 是 Size 中类型描述符和 itab 占用的字节数
+	Metadata uint64 `json:"metadata"`
+	// Memory 是运行时占用的静态内存：.data、.noptrdata 中的符号加上不占文件空间的 .bss、.noptrbss（仅 ELF）
+	Memory  uint64    `json:"memory,omitempty"`
+	Symbols []*Symbol `json:"symbols,omitempty"`
+}
+
+// AnalyzeBinary 分析二进制文件，按模块、包和符号归属链接的字节数
+// Go 归档文件和目标文件没有 buildinfo，按包统计链接之前的符号大小
+func (g *GoWeight) AnalyzeBinary(binaryPath string) (*Report, error) {
+	if isGoArchive(binaryPath) {
+		stat, err := os.Stat(binaryPath)
+		if err != nil {
+			return nil, err
+		}
+		return analyzeArchive(binaryPath, uint64(stat.Size()))
+	}
+	info, err := readBuildInfo(binaryPath, g.Arch)
+	if err != nil {
+		return nil, fmt.Errorf("reading build info from binary %s: %w", binaryPath, err)
+	}
+	f, err := openBinary(binaryPath, g.Arch)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	report := &Report{
+		SchemaVersion: ReportSchemaVersion,
+		Binary:        BinaryInfo{Path: binaryPath, Size: uint64(f.Size()), Arch: f.Arch},
+		Build:         buildMetadata(info),
+	}
+	if f.Arch != "" {
+		report.Binary.Arches, _ = FatArches(binaryPath)
+	}
+
+	var debugPath string
+	if g.DebugFile != "" || len(g.DebugDirs) > 0 {
+		if debugPath, err = findDebugFile(binaryPath, g.DebugFile, g.DebugDirs); err != nil {
+			if g.DebugFile != "" {
+				return nil, err
+			}
+			log.Printf("Warning: %v", err)
+		}
+		report.Binary.DebugFile = debugPath
+	}
+
+	modules := buildModules(info)
+	image, err := readBinarySymbols(binaryPath, f.Arch, debugPath, g.View == ViewLogical)
+	if err != nil {
+		// 如果无法分析符号表，则尝试从模块缓存估算大小
+		log.Printf("Warning: Could not analyze symbol table: %v", err)
+		report.Source = SourceEstimate
+		for _, module := range modules {
+			if module.Path != StdModule {
+				module.Size = estimateModuleSize(module.Path, module.Version)
+			}
+		}
+	} else {
+		report.Binary.Format = image.Format
+		report.Binary.Libraries = image.Libraries
+		if report.DataSegments = image.DataSegments; report.DataSegments != nil {
+			report.DataSegments.SizeHuman = humanize.Bytes(report.DataSegments.Size)
+		}
+		report.Source = image.Source
+		if image.Logical {
+			report.View = ViewLogical
+			report.Totals.Inlined = image.Inlined
+		} else if g.View == ViewLogical {
+			log.Printf("Warning: no DWARF in %s, showing the physical view (use --debug-file for stripped binaries)", binaryPath)
+		}
+		modules = attributeSymbols(modules, image.Symbols, image.BSS, info.Path)
+		for _, module := range modules {
+			report.Totals.Attributed += module.Size
+			report.Totals.Memory += module.Memory
+			report.Totals.Packages += len(module.Packages)
+		}
+		report.Totals.Symbols = len(image.Symbols)
+		report.Totals.Strings = image.Strings
+
+		if report.Embeds, err = readEmbedFS(binaryPath, f.Arch, image.Symbols, info.Path); err != nil {
+			log.Printf("Warning: reading embed.FS tables: %v", err)
+		}
+		for _, e := range report.Embeds {
+			report.Totals.Embedded += e.Size
+		}
+	}
+
+	report.setModules(modules)
+	return report, nil
+}
+
+// readBuildInfo 读取二进制文件（通用二进制文件中 arch 对应的切片）中的构建信息，
+// debug/buildinfo 不支持的 WebAssembly 模块单独处理
+func readBuildInfo(binaryPath, arch string) (*buildinfo.BuildInfo, error) {
+	if isWasm(binaryPath) {
+		return readWasmBuildInfo(binaryPath)
+	}
+	f, err := openBinary(binaryPath, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return buildinfo.Read(f)
+}
+
+// buildModules 返回 buildinfo 中记录的主模块和依赖模块，以及标准库
+func buildModules(info *buildinfo.BuildInfo) []*ModuleReport {
+	var modules []*ModuleReport
+	if info.Main.Path != "" {
+		modules = append(modules, &ModuleReport{Path: info.Main.Path, Version: info.Main.Version, Main: true})
+	}
+	for _, dep := range info.Deps {
+		if dep == nil {
+			continue
+		}
+		module := &ModuleReport{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			module.Replace = &ModuleVersion{Path: dep.Replace.Path, Version: dep.Replace.Version}
+		}
+		modules = append(modules, module)
+	}
+	return append(modules, &ModuleReport{Path: StdModule})
+}
+
+// setModules 按大小排列模块并算出整体的归属比例，Totals.Attributed 必须已经累加好
+func (r *Report) setModules(modules []*ModuleReport) {
+	for _, module := range modules {
+		module.SizeHuman = humanize.Bytes(module.Size)
+	}
+	sort.SliceStable(modules, func(i, j int) bool { return modules[i].Size > modules[j].Size })
+	r.Modules = modules
+
+	r.Totals.BinarySize = r.Binary.Size
+	if r.Totals.Attributed <= r.Totals.BinarySize {
+		r.Totals.Unattributed = r.Totals.BinarySize - r.Totals.Attributed
+	}
+	if r.Totals.BinarySize > 0 {
+		r.Totals.Coverage = float64(r.Totals.Attributed) / float64(r.Totals.BinarySize)
+	}
+	r.Totals.Modules = len(modules)
+}
+
+// attributeSymbols 把符号按包归类，bss 中的符号只计入包的静态内存，再把包归入路径前缀最长的模块
+// 不属于任何依赖模块的包（如 fmt、internal/abi、vendor/golang.org/x/net/...）归入标准库，
+// <cgo> 包归入同名的伪模块，返回可能追加了 <cgo> 模块的模块列表
+func attributeSymbols(modules []*ModuleReport, symbols, bss []Symbol, mainPath string) []*ModuleReport {
+	packages := make(map[string]*PackageReport)
+	lookup := func(path string) *PackageReport {
+		// main 包的符号以 main. 开头，换回它真实的导入路径
+		if path == "main" && mainPath != "" {
+			path = mainPath
+		}
+		p, ok := packages[path]
+		if !ok {
+			p = &PackageReport{Path: path}
+			packages[path] = p
+		}
+		return p
+	}
+	for i := range symbols {
+		sym := &symbols[i]
+		p := lookup(sym.Package)
+		p.Size += sym.Size
+		if sym.Kind == KindType || sym.Kind == KindItab {
+			p.Metadata += sym.Size
+		}
+		if memorySections[sym.Section] {
+			p.Memory += sym.Size
+		}
+		p.Symbols = append(p.Symbols, sym)
+	}
+	// bss 只占内存，只有 bss 变量的包大小为 0
+	for _, sym := range bss {
+		lookup(sym.Package).Memory += sym.Size
+	}
+
+	var std *ModuleReport
+	for _, module := range modules {
+		if module.Path == StdModule {
+			std = module
+		}
+	}
+	for _, p := range packages {
+		owner := std
+		if p.Path == CgoPackage {
+			owner = &ModuleReport{Path: CgoPackage}
+			modules = append(modules, owner)
+		}
+		for _, module := range modules {
+			if module == std || owner.Path == CgoPackage {
+				continue
+			}
+			if p.Path == module.Path || strings.HasPrefix(p.Path, module.Path+"/") {
+				if owner == std || len(module.Path) > len(owner.Path) {
+					owner = module
+				}
+			}
+		}
+		owner.Packages = append(owner.Packages, p)
+		owner.Size += p.Size
+		owner.Metadata += p.Metadata
+		owner.Memory += p.Memory
+	}
+
+	for _, module := range modules {
+		for _, p := range module.Packages {
+			p.SizeHuman = humanize.Bytes(p.Size)
+			sort.SliceStable(p.Symbols, func(i, j int) bool { return p.Symbols[i].Size > p.Symbols[j].Size })
+		}
+		sort.Slice(module.Packages, func(i, j int) bool {
+			if module.Packages[i].Size != module.Packages[j].Size {
+				return module.Packages[i].Size > module.Packages[j].Size
+			}
+			return module.Packages[i].Path < module.Packages[j].Path
+		})
+	}
+	return modules
+}
+
+// buildMetadata 从 buildinfo 中提取报告需要的构建信息
+func buildMetadata(info *buildinfo.BuildInfo) BuildMetadata {
+	meta := BuildMetadata{
+		GoVersion:  info.GoVersion,
+		Path:       info.Path,
+		MainModule: ModuleVersion{Path: info.Main.Path, Version: info.Main.Version},
+	}
+	vcs := func() *VCSInfo {
+		if meta.VCS == nil {
+			meta.VCS = &VCSInfo{}
+		}
+		return meta.VCS
+	}
+	for _, s := range info.Settings {
+		meta.Settings = append(meta.Settings, BuildSetting{Key: s.Key, Value: s.Value})
+		switch s.Key {
+		case "GOOS":
+			meta.GOOS = s.Value
+		case "GOARCH":
+			meta.GOARCH = s.Value
+		case "vcs":
+			vcs().System = s.Value
+		case "vcs.revision":
+			vcs().Revision = s.Value
+		case "vcs.time":
+			vcs().Time = s.Value
+		case "vcs.modified":
+			vcs().Modified = s.Value == "true"
+		}
+	}
+	return meta
+}
+
+// ModuleEntries 把报告中的依赖模块转换为 ModuleEntry 列表（不含标准库）
+func (r *Report) ModuleEntries() []*ModuleEntry {
+	var modules []*ModuleEntry
+	for _, module := range r.Modules {
+		// 标准库不是依赖，不在模块列表中显示
+		if module.Path == StdModule {
+			continue
+		}
+		modules = append(modules, &ModuleEntry{
+			Path:      module.Path,
+			Name:      module.Path,
+			Version:   module.Version,
+			Origin:    module.Origin,
+			Size:      module.Size,
+			SizeHuman: module.SizeHuman,
+			Metadata:  module.Metadata,
+			Memory:    module.Memory,
+		})
+	}
+	return modules
+}
+
+// Packages 返回报告中所有包，按大小降序排列
+func (r *Report) Packages() []*PackageReport {
+	var packages []*PackageReport
+	for _, module := range r.Modules {
+		packages = append(packages, module.Packages...)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Size != packages[j].Size {
+			return packages[i].Size > packages[j].Size
+		}
+		return packages[i].Path < packages[j].Path
+	})
+	return packages
+}
+
+// WithoutSymbols 返回去掉符号列表的报告副本，用于非详细模式的 JSON 输出
+func (r *Report) WithoutSymbols() *Report {
+	clone := *r
+	clone.Modules = make([]*ModuleReport, len(r.Modules))
+	for i, module := range r.Modules {
+		m := *module
+		m.Packages = make([]*PackageReport, len(module.Packages))
+		for j, p := range module.Packages {
+			pc := *p
+			pc.Symbols = nil
+			m.Packages[j] = &pc
+		}
+		clone.Modules[i] = &m
+	}
+	return &clone
+}
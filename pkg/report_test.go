@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"debug/buildinfo"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+// buildTestBinary 在临时目录中用 files 创建模块 example.com/app 并构建，返回二进制文件路径
+// env 追加到构建环境（如 GOOS=darwin），args 追加到 go build 参数
+func buildTestBinary(t *testing.T, files map[string]string, env []string, args ...string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping go build in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir := t.TempDir()
+	if _, ok := files["go.mod"]; !ok {
+		files["go.mod"] = "module example.com/app\n\ngo 1.24\n"
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(dir, "app")
+	cmd := exec.Command("go", append([]string{"build", "-o", output}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off", "CGO_ENABLED=0")
+	cmd.Env = append(cmd.Env, env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	return output
+}
+
+const helloMain = `package main
+
+import "fmt"
+
+func main() { fmt.Println("hello") }
+`
+
+func TestAnalyzeBinary(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{"main.go": helloMain}, nil)
+
+	report, err := (&GoWeight{}).AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.SchemaVersion != ReportSchemaVersion {
+		t.Errorf("schema version = %d", report.SchemaVersion)
+	}
+	if report.Build.Path != "example.com/app" || report.Build.MainModule.Path != "example.com/app" {
+		t.Errorf("build = %+v", report.Build)
+	}
+	if report.Source != SourceSymtab {
+		t.Errorf("source = %q, want %q", report.Source, SourceSymtab)
+	}
+
+	totals := report.Totals
+	if totals.BinarySize == 0 || totals.Attributed+totals.Unattributed != totals.BinarySize {
+		t.Errorf("totals do not add up: %+v", totals)
+	}
+	if totals.Modules != len(report.Modules) {
+		t.Errorf("totals.modules = %d, have %d modules", totals.Modules, len(report.Modules))
+	}
+
+	sizes := make(map[string]uint64)
+	for _, p := range report.Packages() {
+		sizes[p.Path] = p.Size
+	}
+	for _, path := range []string{"example.com/app", "fmt", "runtime"} {
+		if sizes[path] == 0 {
+			t.Errorf("package %s has no linked bytes", path)
+		}
+	}
+	if _, ok := sizes["main"]; ok {
+		t.Error("main package is reported as \"main\" instead of its import path")
+	}
+	// 来源分类只在调用方要求时计算
+	for _, m := range report.Modules {
+		if m.Origin != "" {
+			t.Errorf("module %s has origin %q without ClassifyOrigins", m.Path, m.Origin)
+		}
+	}
+}
+
+func TestWithoutSymbols(t *testing.T) {
+	sym := &Symbol{Name: "example.com/app.F", Size: 10}
+	report := &Report{Modules: []*ModuleReport{{
+		Path:     "example.com/app",
+		Packages: []*PackageReport{{Path: "example.com/app", Size: 10, Symbols: []*Symbol{sym}}},
+	}}}
+
+	clone := report.WithoutSymbols()
+	if clone.Modules[0].Packages[0].Symbols != nil {
+		t.Error("clone still has symbols")
+	}
+	if len(report.Modules[0].Packages[0].Symbols) != 1 {
+		t.Error("original report lost its symbols")
+	}
+}
+
+func TestBuildMetadata(t *testing.T) {
+	info := &buildinfo.BuildInfo{
+		GoVersion: "go1.24.0",
+		Path:      "example.com/app/cmd/app",
+		Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "GOOS", Value: "linux"},
+			{Key: "GOARCH", Value: "arm64"},
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2024-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	meta := buildMetadata(info)
+
+	want := BuildMetadata{
+		GoVersion:  "go1.24.0",
+		GOOS:       "linux",
+		GOARCH:     "arm64",
+		Path:       "example.com/app/cmd/app",
+		MainModule: ModuleVersion{Path: "example.com/app", Version: "(devel)"},
+		VCS:        &VCSInfo{System: "git", Revision: "abc123", Time: "2024-01-02T03:04:05Z", Modified: true},
+	}
+	meta.Settings = nil
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("buildMetadata = %+v, want %+v", meta, want)
+	}
+
+	if meta := buildMetadata(&buildinfo.BuildInfo{GoVersion: "go1.24.0"}); meta.VCS != nil {
+		t.Errorf("VCS without vcs settings = %+v, want nil", meta.VCS)
+	}
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// GroupByRules 按规则文件中定义的分组聚合
+const GroupByRules = "rules"
+
+// DefaultOtherGroup 是没有匹配任何规则的包所在的分组
+const DefaultOtherGroup = "other"
+
+// Rules 是从规则文件加载的自定义分组，按顺序匹配，第一个命中的分组生效
+//
+//	{
+//	  "groups": [
+//	    {"name": "cloud SDKs", "patterns": ["github.com/aws/...", "cloud.google.com/go/..."], "budget": "20MB"},
+//	    {"name": "observability", "regexps": ["^go\\.opentelemetry\\.io/", "prometheus"]},
+//	    {"name": "std", "patterns": ["std"]}
+//	  ],
+//	  "other": "other"
+//	}
+type Rules struct {
+	Groups []*RuleGroup `json:"groups"`
+	Other  string       `json:"other,omitempty"`
+}
+
+// RuleGroup 是一个命名分组
+// patterns 是路径通配符：* 匹配一段路径，** 或 ... 匹配任意多段，不含通配符时匹配该路径及其子包
+// regexps 是正则表达式；两者都同时匹配包路径和模块路径（标准库的模块路径为 std）
+type RuleGroup struct {
+	Name     string   `json:"name"`
+	Patterns []string `json:"patterns,omitempty"`
+	Regexps  []string `json:"regexps,omitempty"`
+	Budget   string   `json:"budget,omitempty"`
+
+	matchers    []*regexp.Regexp
+	budgetBytes uint64
+}
+
+// LoadRules 读取并编译规则文件
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := &Rules{}
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("parsing rules file %s: %w", path, err)
+	}
+	if err := rules.compile(); err != nil {
+		return nil, fmt.Errorf("rules file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// compile 编译通配符和正则表达式，解析预算
+func (r *Rules) compile() error {
+	if r.Other == "" {
+		r.Other = DefaultOtherGroup
+	}
+	for i, g := range r.Groups {
+		if g.Name == "" {
+			return fmt.Errorf("group %d has no name", i+1)
+		}
+		for _, p := range g.Patterns {
+			re, err := regexp.Compile(globToRegexp(p))
+			if err != nil {
+				return fmt.Errorf("group %q: pattern %q: %w", g.Name, p, err)
+			}
+			g.matchers = append(g.matchers, re)
+		}
+		for _, expr := range g.Regexps {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("group %q: regexp %q: %w", g.Name, expr, err)
+			}
+			g.matchers = append(g.matchers, re)
+		}
+		if g.Budget != "" {
+			budget, err := humanize.ParseBytes(g.Budget)
+			if err != nil {
+				return fmt.Errorf("group %q: budget %q: %w", g.Name, g.Budget, err)
+			}
+			g.budgetBytes = budget
+		}
+	}
+	return nil
+}
+
+// globToRegexp 把路径通配符转换为锚定的正则表达式
+func globToRegexp(pattern string) string {
+	pattern = strings.ReplaceAll(pattern, "...", "**")
+	// 与 go 命令一样，a/... 同时匹配 a 本身
+	pattern, subtree := strings.CutSuffix(pattern, "/**")
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if subtree || !strings.ContainsAny(pattern, "*?") {
+		// 不含通配符时同时匹配子包
+		b.WriteString("(/.*)?")
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// Match 返回包所属的分组名，module 为包所在的模块路径
+func (r *Rules) Match(pkgPath, module string) string {
+	for _, g := range r.Groups {
+		for _, re := range g.matchers {
+			if re.MatchString(pkgPath) || (module != "" && re.MatchString(module)) {
+				return g.Name
+			}
+		}
+	}
+	return r.Other
+}
+
+// BudgetViolation 是一个超出预算的分组
+type BudgetViolation struct {
+	Group  string `json:"group"`
+	Size   uint64 `json:"size"`
+	Budget uint64 `json:"budget"`
+}
+
+func (v BudgetViolation) String() string {
+	return fmt.Sprintf("%s is %s, over its budget of %s", v.Group, humanize.Bytes(v.Size), humanize.Bytes(v.Budget))
+}
+
+// CheckBudgets 用按规则聚合后的条目检查各分组的预算
+func (r *Rules) CheckBudgets(entries []*ModuleEntry) []BudgetViolation {
+	sizes := make(map[string]uint64)
+	for _, e := range entries {
+		sizes[e.Name] += e.Size
+	}
+	var violations []BudgetViolation
+	for _, g := range r.Groups {
+		if g.budgetBytes > 0 && sizes[g.Name] > g.budgetBytes {
+			violations = append(violations, BudgetViolation{Group: g.Name, Size: sizes[g.Name], Budget: g.budgetBytes})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"github.com/aws/...", "github.com/aws", true},
+		{"github.com/aws/...", "github.com/aws/aws-sdk-go-v2/service/s3", true},
+		{"github.com/aws/...", "github.com/awslabs/smithy-go", false},
+		{"github.com/aws/**", "github.com/aws/aws-sdk-go-v2", true},
+		{"github.com/*/kingpin", "github.com/alecthomas/kingpin", true},
+		{"github.com/*/kingpin", "github.com/a/b/kingpin", false},
+		{"github.com/*/kingpin", "github.com/alecthomas/kingpin/v2", false},
+		{"golang.org/x/net", "golang.org/x/net/http2", true},
+		{"golang.org/x/net", "golang.org/x/netutil", false},
+		{"gopkg.in/yaml.v?", "gopkg.in/yaml.v3", true},
+		{"gopkg.in/yaml.v?", "gopkg.in/yamlxv3", false},
+		{"**/internal/**", "github.com/a/internal/b", true},
+		{"std", "std", true},
+	}
+	for _, tt := range tests {
+		rules := &Rules{Groups: []*RuleGroup{{Name: "g", Patterns: []string{tt.pattern}}}}
+		if err := rules.compile(); err != nil {
+			t.Fatal(err)
+		}
+		if got := rules.Match(tt.path, "") == "g"; got != tt.want {
+			t.Errorf("pattern %q on %q = %v, want %v (regexp %s)", tt.pattern, tt.path, got, tt.want, globToRegexp(tt.pattern))
+		}
+	}
+}
+
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRulesMatch(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, `{
+		"groups": [
+			{"name": "cloud", "patterns": ["github.com/aws/...", "cloud.google.com/go/..."], "budget": "1KB"},
+			{"name": "observability", "regexps": ["^go\\.opentelemetry\\.io/", "prometheus"]},
+			{"name": "std", "patterns": ["std"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pkg    string
+		module string
+		want   string
+	}{
+		{"github.com/aws/aws-sdk-go-v2/service/s3", "github.com/aws/aws-sdk-go-v2/service/s3", "cloud"},
+		{"go.opentelemetry.io/otel/trace", "go.opentelemetry.io/otel/trace", "observability"},
+		{"github.com/prometheus/client_golang/prometheus", "github.com/prometheus/client_golang", "observability"},
+		// 标准库通过模块路径 std 匹配
+		{"net/http", StdModule, "std"},
+		{"github.com/alecthomas/kingpin/v2", "github.com/alecthomas/kingpin/v2", DefaultOtherGroup},
+	}
+	for _, tt := range tests {
+		if got := rules.Match(tt.pkg, tt.module); got != tt.want {
+			t.Errorf("Match(%q, %q) = %q, want %q", tt.pkg, tt.module, got, tt.want)
+		}
+	}
+
+	violations := rules.CheckBudgets([]*ModuleEntry{
+		{Name: "cloud", Size: 1500},
+		{Name: "observability", Size: 1 << 20},
+	})
+	want := []BudgetViolation{{Group: "cloud", Size: 1500, Budget: 1000}}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("CheckBudgets = %+v, want %+v", violations, want)
+	}
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`{"groups": [{"patterns": ["a"]}]}`, "has no name"},
+		{`{"groups": [{"name": "a", "regexps": ["("]}]}`, `regexp "("`},
+		{`{"groups": [{"name": "a", "budget": "lots"}]}`, `budget "lots"`},
+		{`{"groups": [`, "parsing rules file"},
+	}
+	for _, tt := range tests {
+		_, err := LoadRules(writeRules(t, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadRules(%s) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package pkg
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed web/index.html
+var indexHTML []byte
+
+// ServerOptions 控制 goweight serve 的 HTTP 服务
+type ServerOptions struct {
+	// Root 限制按路径分析时可以读取的目录，为空时只接受上传
+	Root string
+	// MaxUpload 是单个请求允许上传的最大字节数
+	MaxUpload int64
+	// Rules 不为空时，差异结果中附带按规则分组的变化
+	Rules *Rules
+}
+
+// Handler 返回提供分析 JSON API 和浏览界面的 HTTP 处理器
+//
+//	GET  /             浏览界面（树状图、表格和差异视图）
+//	POST /api/analyze  上传 binary 文件或提供 path 参数，返回 Report
+//	POST /api/diff     上传 old/new 文件或提供 old_path/new_path 参数，返回 ReportDiff
+func (g *GoWeight) Handler(opts ServerOptions) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(indexHTML)
+	})
+
+	mux.HandleFunc("POST /api/analyze", func(w http.ResponseWriter, r *http.Request) {
+		if err := parseUpload(w, r, opts); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		binary, err := requestBinary(r, opts, "binary", "path")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		defer binary.cleanup()
+
+		report, err := g.AnalyzeBinary(binary.path)
+		if err != nil {
+			writeJSONError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		report.Binary.Path = binary.name
+		if r.FormValue("symbols") == "" {
+			report = report.WithoutSymbols()
+		}
+		writeJSON(w, report)
+	})
+
+	mux.HandleFunc("POST /api/diff", func(w http.ResponseWriter, r *http.Request) {
+		if err := parseUpload(w, r, opts); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		var reports [2]*Report
+		for i, field := range []string{"old", "new"} {
+			binary, err := requestBinary(r, opts, field, field+"_path")
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, err)
+				return
+			}
+			report, err := g.AnalyzeBinary(binary.path)
+			binary.cleanup()
+			if err != nil {
+				writeJSONError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s: %w", field, err))
+				return
+			}
+			report.Binary.Path = binary.name
+			reports[i] = report
+		}
+		diff := DiffReports(reports[0], reports[1])
+		if opts.Rules != nil {
+			diff.Groups = DiffGroups(reports[0], reports[1], Grouping{Mode: GroupByRules, Rules: opts.Rules})
+		}
+		writeJSON(w, diff)
+	})
+
+	return mux
+}
+
+// parseUpload 解析 multipart 表单并限制请求体大小
+func parseUpload(w http.ResponseWriter, r *http.Request, opts ServerOptions) error {
+	if opts.MaxUpload > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, opts.MaxUpload)
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.ParseMultipartForm(32 << 20)
+	}
+	return r.ParseForm()
+}
+
+// requestedBinary 是请求中指定的二进制文件
+type requestedBinary struct {
+	path    string // 本地可读的路径
+	name    string // 报告中显示的名字
+	cleanup func()
+}
+
+// requestBinary 取出上传的文件（保存到临时文件）或按路径参数定位服务器上的文件
+func requestBinary(r *http.Request, opts ServerOptions, fileField, pathField string) (*requestedBinary, error) {
+	if r.MultipartForm != nil {
+		if file, header, err := r.FormFile(fileField); err == nil {
+			defer file.Close()
+			tmp, err := os.CreateTemp("", "goweight-upload-*")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := io.Copy(tmp, file); err != nil {
+				tmp.Close()
+				os.Remove(tmp.Name())
+				return nil, err
+			}
+			tmp.Close()
+			return &requestedBinary{
+				path:    tmp.Name(),
+				name:    header.Filename,
+				cleanup: func() { os.Remove(tmp.Name()) },
+			}, nil
+		}
+	}
+
+	path := r.FormValue(pathField)
+	if path == "" {
+		return nil, fmt.Errorf("upload a %q file or provide %q", fileField, pathField)
+	}
+	resolved, err := resolveServedPath(opts.Root, path)
+	if err != nil {
+		return nil, err
+	}
+	return &requestedBinary{path: resolved, name: path, cleanup: func() {}}, nil
+}
+
+// resolveServedPath 把路径参数解析到 root 之内，拒绝通过 .. 或符号链接逃出 root
+func resolveServedPath(root, path string) (string, error) {
+	if root == "" {
+		return "", errors.New("analyzing server paths is disabled; upload the binary instead")
+	}
+	rootAbs, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	rootAbs, err = filepath.Abs(rootAbs)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(rootAbs, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(rootAbs, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of %s", path, root)
+	}
+	return resolved, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Warning: writing response: %v", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
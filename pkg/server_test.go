@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveServedPath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(root, "app"), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o644)
+	os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "link"))
+
+	tests := []struct {
+		root    string
+		path    string
+		wantErr bool
+	}{
+		{root, "app", false},
+		{root, filepath.Join(root, "app"), false},
+		{root, "../" + filepath.Base(outside) + "/secret", true},
+		{root, filepath.Join(outside, "secret"), true},
+		{root, "link", true},
+		{root, "missing", true},
+		{"", "app", true},
+	}
+	for _, tt := range tests {
+		_, err := resolveServedPath(tt.root, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolveServedPath(%q, %q) error = %v, wantErr %v", tt.root, tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHandlerMethods(t *testing.T) {
+	handler := (&GoWeight{}).Handler(ServerOptions{})
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/", http.StatusOK},
+		{http.MethodPost, "/", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/analyze", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/api/analyze", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/diff", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/analyze", http.StatusBadRequest},
+		{http.MethodGet, "/missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestHandlerPathsNeedRoot(t *testing.T) {
+	handler := (&GoWeight{}).Handler(ServerOptions{})
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze", strings.NewReader(url.Values{"path": {"app"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "disabled") {
+		t.Errorf("path without root = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandlerAnalyzeUpload(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{"main.go": helloMain}, nil)
+	content, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(limit int64) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, _ := mw.CreateFormFile("binary", "hello")
+		fw.Write(content)
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/analyze", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rec := httptest.NewRecorder()
+		(&GoWeight{}).Handler(ServerOptions{MaxUpload: limit}).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := upload(0)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload = %d %s", rec.Code, rec.Body.String())
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Binary.Path != "hello" || report.Build.Path != "example.com/app" {
+		t.Errorf("report binary = %+v, build path %q", report.Binary, report.Build.Path)
+	}
+
+	if rec := upload(1024); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("upload over the limit = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
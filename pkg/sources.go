@@ -0,0 +1,170 @@
+package pkg
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/dustin/go-humanize"
+)
+
+// SourceReport 把代码字节按源文件和目录归属
+type SourceReport struct {
+	Files []*SourceFile `json:"files"`
+	Dirs  []*SourceDir  `json:"dirs"`
+	// Unattributed 是在 pclntab 中找不到行号信息的函数字节数（C 函数归入它的翻译单元）
+	Unattributed uint64 `json:"unattributed"`
+}
+
+// SourceFile 是一个源文件编译出的代码
+type SourceFile struct {
+	Path      string `json:"path"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+	// Lines 是按大小降序排列的行，只在要求按行统计时出现
+	Lines []*SourceLine `json:"lines,omitempty"`
+}
+
+// SourceLine 是一行源代码编译出的代码
+type SourceLine struct {
+	Line      int    `json:"line"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+}
+
+// SourceDir 汇总同一个目录中的源文件
+type SourceDir struct {
+	Path      string `json:"path"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+	Files     int    `json:"files"`
+}
+
+// AnalyzeSources 把报告中每个函数的字节按 pclntab 的 pc-file 和 pc-line 表拆分到源文件（和行）上
+// 内联的代码计入被内联函数所在的文件；报告必须包含符号（没有经过 WithoutSymbols）
+func (g *GoWeight) AnalyzeSources(report *Report, lines bool) (*SourceReport, error) {
+	if report.Binary.Format == "wasm" || report.Binary.Format == FormatArchive || report.Binary.Format == FormatObject {
+		return nil, fmt.Errorf("source attribution needs the pclntab of a linked binary, not %s", report.Binary.Format)
+	}
+	f, err := openBinary(report.Binary.Path, report.Binary.Arch)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, textStart, err := readPclntab(f)
+	if err != nil {
+		return nil, err
+	}
+	table, err := newPCTable(data, textStart)
+	if err != nil {
+		return nil, err
+	}
+	funcs := table.funcs()
+
+	files := make(map[string]*SourceFile)
+	fileLines := make(map[string]map[int]uint64)
+	add := func(name string, line int, size uint64) {
+		sf, ok := files[name]
+		if !ok {
+			sf = &SourceFile{Path: name}
+			files[name] = sf
+			fileLines[name] = make(map[int]uint64)
+		}
+		sf.Size += size
+		if lines && line > 0 {
+			fileLines[name][line] += size
+		}
+	}
+
+	result := &SourceReport{}
+	for _, p := range report.Packages() {
+		for _, sym := range p.Symbols {
+			if sym.Kind != KindFunc && sym.Kind != KindClosure {
+				continue
+			}
+			size := sym.Size
+			if sym.Span > 0 {
+				size = sym.Span // 逻辑视图拆走的内联代码仍在这个函数的地址范围里
+			}
+			fn, ok := funcs[sym.Address]
+			if !ok {
+				if sym.Unit != "" {
+					add(sym.Unit, 0, size)
+				} else {
+					result.Unattributed += size
+				}
+				continue
+			}
+			end := sym.Address + size
+			covered := uint64(0)
+			table.fileLines(fn, sym.Address, end, func(start, stop uint64, file string, line int) {
+				if file == "" {
+					return
+				}
+				add(file, line, stop-start)
+				covered += stop - start
+			})
+			// 函数之间的对齐填充没有行号
+			result.Unattributed += size - covered
+		}
+	}
+
+	dirs := make(map[string]*SourceDir)
+	for name, sf := range files {
+		sf.SizeHuman = humanize.Bytes(sf.Size)
+		for line, size := range fileLines[name] {
+			sf.Lines = append(sf.Lines, &SourceLine{Line: line, Size: size, SizeHuman: humanize.Bytes(size)})
+		}
+		sort.Slice(sf.Lines, func(i, j int) bool {
+			if sf.Lines[i].Size != sf.Lines[j].Size {
+				return sf.Lines[i].Size > sf.Lines[j].Size
+			}
+			return sf.Lines[i].Line < sf.Lines[j].Line
+		})
+		result.Files = append(result.Files, sf)
+
+		dir := path.Dir(name)
+		d, ok := dirs[dir]
+		if !ok {
+			d = &SourceDir{Path: dir}
+			dirs[dir] = d
+		}
+		d.Size += sf.Size
+		d.Files++
+	}
+	for _, d := range dirs {
+		d.SizeHuman = humanize.Bytes(d.Size)
+		result.Dirs = append(result.Dirs, d)
+	}
+	sort.Slice(result.Files, func(i, j int) bool {
+		if result.Files[i].Size != result.Files[j].Size {
+			return result.Files[i].Size > result.Files[j].Size
+		}
+		return result.Files[i].Path < result.Files[j].Path
+	})
+	sort.Slice(result.Dirs, func(i, j int) bool {
+		if result.Dirs[i].Size != result.Dirs[j].Size {
+			return result.Dirs[i].Size > result.Dirs[j].Size
+		}
+		return result.Dirs[i].Path < result.Dirs[j].Path
+	})
+	return result, nil
+}
+
+// readPclntab 返回 ELF、Mach-O 或 PE 文件中的 pclntab 数据和代码段起始地址
+func readPclntab(r io.ReaderAt) ([]byte, uint64, error) {
+	if f, err := elf.NewFile(r); err == nil {
+		return elfPclntab(f)
+	}
+	if f, err := macho.NewFile(r); err == nil {
+		return machoPclntab(f)
+	}
+	if f, err := pe.NewFile(r); err == nil {
+		return pePclntab(f)
+	}
+	return nil, 0, fmt.Errorf("not an ELF, Mach-O or PE binary")
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNewPCTableErrors(t *testing.T) {
+	header := func(magic uint32, ptrSize byte, words ...uint64) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, magic)
+		b = append(b, 0, 0, 1, ptrSize)
+		for _, w := range words {
+			b = binary.LittleEndian.AppendUint64(b, w)
+		}
+		return b
+	}
+	valid := header(0xfffffff1, 8, 0, 0, 0, 0, 72, 72, 72, 72, 0)
+	if _, err := newPCTable(valid, 0x1000); err != nil {
+		t.Fatalf("empty pclntab: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"short", []byte{0xf1, 0xff}},
+		{"go1.16", header(0xfffffffa, 8, 0, 0, 0, 0, 72, 72, 72, 72)},
+		{"pointer size", header(0xfffffff1, 2, 0, 0, 0, 0, 72, 72, 72, 72)},
+		{"truncated header", header(0xfffffff1, 8, 0, 0, 0)},
+		{"table out of range", header(0xfffffff1, 8, 0, 0, 0, 0, 72, 72, 1000, 72)},
+		{"function table", header(0xfffffff1, 8, 3, 0, 0, 0, 72, 72, 72, 72)},
+		{"huge function count", header(0xfffffff1, 8, math.MaxUint64, 0, 0, 0, 72, 72, 72, 72)},
+		{"overflowing function count", header(0xfffffff1, 8, math.MaxInt64/4, 0, 0, 0, 72, 72, 72, 72)},
+	}
+	for _, tt := range tests {
+		if _, err := newPCTable(tt.data, 0x1000); err == nil {
+			t.Errorf("%s: newPCTable did not fail", tt.name)
+		}
+	}
+}
+
+const sourcesUtil = `package main
+
+import "strings"
+
+//go:noinline
+func shout(s string) string {
+	return strings.ToUpper(s) + "!"
+}
+`
+
+const sourcesMain = `package main
+
+import "fmt"
+
+func main() { fmt.Println(shout("hello")) }
+`
+
+func TestAnalyzeSources(t *testing.T) {
+	binary := buildTestBinary(t, map[string]string{"main.go": sourcesMain, "util.go": sourcesUtil}, nil)
+
+	g := &GoWeight{}
+	report, err := g.AnalyzeBinary(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources, err := g.AnalyzeSources(report, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var util *SourceFile
+	var files uint64
+	for _, f := range sources.Files {
+		files += f.Size
+		if strings.HasSuffix(f.Path, "/util.go") {
+			util = f
+		}
+	}
+	if util == nil || util.Size == 0 {
+		t.Fatalf("util.go not found in %d files", len(sources.Files))
+	}
+	// shout 的代码在第 6 到第 8 行
+	for _, l := range util.Lines {
+		if l.Line < 6 || l.Line > 8 {
+			t.Errorf("util.go line %d has %d bytes", l.Line, l.Size)
+		}
+	}
+
+	var dirs uint64
+	for _, d := range sources.Dirs {
+		dirs += d.Size
+	}
+	if dirs != files {
+		t.Errorf("directories add up to %d bytes, files to %d", dirs, files)
+	}
+	var code uint64
+	for _, p := range report.Packages() {
+		for _, sym := range p.Symbols {
+			if sym.Kind == KindFunc || sym.Kind == KindClosure {
+				code += sym.Size
+			}
+		}
+	}
+	if files+sources.Unattributed != code {
+		t.Errorf("files %d + unattributed %d != function bytes %d", files, sources.Unattributed, code)
+	}
+
+	report.Binary.Format = FormatArchive
+	if _, err := g.AnalyzeSources(report, false); err == nil {
+		t.Error("source attribution of an archive did not fail")
+	}
+}
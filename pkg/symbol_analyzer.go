@@ -3,127 +3,217 @@ package pkg
 import (
 	"debug/dwarf"
 	"debug/elf"
+	"debug/gosym"
 	"debug/macho"
 	"debug/pe"
+	"encoding/binary"
 	"fmt"
-	"os"
+	"regexp"
+	"sort"
 	"strings"
 )
 
 // Section 表示二进制文件中的一个节
 type Section struct {
 	Name string
+	Addr uint64
 	Size uint64
 	Type string
 }
 
 // Symbol 表示一个符号及其相关信息
 type Symbol struct {
-	Name    string
-	Size    uint64
-	Address uint64
-	Package string
-	Section string
+	Name    string `json:"name"`
+	Size    uint64 `json:"size"`
+	Address uint64 `json:"address,omitempty"`
+	Package string `json:"-"`
+	Section string `json:"section,omitempty"`
+	// Generic 是泛型实例化符号去掉类型参数后的原始函数名
+	Generic string `json:"generic,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	// Unit 是 C 符号所在的翻译单元（DWARF 编译单元的源文件路径）
+	Unit string `json:"unit,omitempty"`
+	// Span 是逻辑视图拆出内联代码之前函数的地址范围长度，0 表示与 Size 相同
+	Span uint64 `json:"-"`
 }
 
-// analyzeBinarySymbolTable 分析二进制文件的符号表来估算各包的大小
-func analyzeBinarySymbolTable(binaryPath string) (map[string]uint64, error) {
-	f, err := os.Open(binaryPath)
+// 符号的种类
+const (
+	KindFunc    = "func"    // 函数
+	KindClosure = "closure" // 闭包以及 go/defer 语句生成的包装函数
+	KindType    = "type"    // 运行时类型描述符 type:*
+	KindItab    = "itab"    // 接口表 go:itab.*
+	KindString  = "string"  // 字符串数据 go:string.*
+	KindData    = "data"    // 其他数据
+	KindReloc   = "reloc"   // 位置无关代码的动态重定位记录 go:rela.*
+	KindInline  = "inline"  // 逻辑视图中内联到其他包的函数里的代码，每个被内联的函数一个符号
+)
+
+// closureSuffix 匹配编译器为闭包和 go/defer 生成的函数名后缀，如 F.func1、F.func1.2、F.gowrap1
+var closureSuffix = regexp.MustCompile(`\.(func|gowrap|deferwrap)[0-9]+(\.[0-9]+)*$`)
+
+// linkerMarker 匹配链接器标记区域边界的符号和 GC 位图的容器符号，ELF 符号表中它们的大小都是 0
+var linkerMarker = regexp.MustCompile(`^runtime\.(e?(text|rodata|types|pclntab|noptrdata|data|bss|noptrbss|gcdata|gcbss|covctrs)|end|gcbits\.\*|gcmask\.\*)$`)
+
+// gccCloneSuffix 匹配 GCC 为拆分和特化的 C 函数以及函数内静态变量生成的后缀，
+// 如 x_cgo_munmap.cold、foo.part.0、bar.constprop.0.isra.0、completed.0
+var gccCloneSuffix = regexp.MustCompile(`^(\.(cold|part|isra|constprop|lto_priv|localalias|[0-9]+))+$`)
+
+// 包大小数据的来源
+const (
+	SourceSymtab   = "symtab"   // 符号表中记录的符号大小
+	SourceDWARF    = "dwarf"    // DWARF 调试信息中的函数地址范围
+	SourcePclntab  = "pclntab"  // Go 运行时的 pclntab 函数表（适用于 -s -w 剥离后的二进制文件）
+	SourceEstimate = "estimate" // 估算值（模块缓存目录大小）
+)
+
+// memorySections 是运行时占用静态内存的 ELF 节：已初始化的数据和零初始化的 bss
+var memorySections = map[string]bool{".data": true, ".noptrdata": true, ".bss": true, ".noptrbss": true}
+
+// binaryImage 汇总从二进制文件中读出的符号和节信息
+type binaryImage struct {
+	Format   string
+	Source   string
+	Symbols  []Symbol
+	Sections []Section
+	// Libraries 是运行时动态链接的共享库（ELF DT_NEEDED 等）
+	Libraries []string
+	// DataSegments 是 WebAssembly 模块的数据段
+	DataSegments *DataSegments
+	// BSS 是 ELF 文件中 .bss 和 .noptrbss 的符号，它们不占文件空间，只计入静态内存
+	BSS []Symbol
+	// Logical 表示内联代码已经计入被内联函数的包，Inlined 是因此从调用方移走的字节数
+	Logical bool
+	Inlined uint64
+	// Strings 是 go:string.* 中字符串字面量的字节数，链接器在包之间共享它们，不归属任何包
+	Strings uint64
+}
+
+// readBinarySymbols 读取二进制文件中可归属到包的符号以及节信息
+// 优先使用符号表，没有符号表时依次退回到 DWARF 和 pclntab；WebAssembly 模块使用代码段和 name 段
+// 通用二进制文件只读取 arch 对应的切片；debugPath 是剥离出去的 ELF 调试文件，符号表和 DWARF 从它读取
+// logical 为 true 且有 DWARF 时按其中的内联信息把内联进来的代码计入被内联函数的包
+func readBinarySymbols(binaryPath, arch, debugPath string, logical bool) (*binaryImage, error) {
+	if isWasm(binaryPath) {
+		return readWasmSymbols(binaryPath)
+	}
+	f, err := openBinary(binaryPath, arch)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	// 尝试解析不同类型的二进制文件
-	var sections []Section
-	var symbols []Symbol
-	var archType string
+	image := &binaryImage{Source: SourceSymtab}
+	var dwarfData func() (*dwarf.Data, error)
+	var pclntab func() ([]byte, uint64, error)
+	// foreign 是不属于任何 Go 包的符号，可能来自 cgo 和 C 库，foreignBSS 是其中未初始化的变量
+	var foreign, foreignBSS []Symbol
+	var order binary.ByteOrder
+	var relocations func([]Symbol) []Symbol
 
 	// 尝试 ELF 格式 (Linux)
 	if elfFile, err := elf.NewFile(f); err == nil {
-		archType = "ELF"
+		image.Format = "ELF"
+		dwarfData = elfFile.DWARF
+		pclntab = func() ([]byte, uint64, error) { return elfPclntab(elfFile) }
+		order = elfFile.ByteOrder
+		image.Libraries, _ = elfFile.ImportedLibraries()
+		relocations = func(symbols []Symbol) []Symbol { return relocationSymbols(elfFile, symbols) }
+
+		// 调试文件中各节的地址和名字与二进制文件相同，但内容都被去掉了（SHT_NOBITS），
+		// 所以节是否占文件空间要看二进制文件中的同名节
+		symFile := elfFile
+		if debugPath != "" {
+			debugFile, err := elf.Open(debugPath)
+			if err != nil {
+				return nil, fmt.Errorf("opening debug file: %w", err)
+			}
+			defer debugFile.Close()
+			symFile = debugFile
+			dwarfData = debugFile.DWARF
+		}
+		noBits := make(map[string]bool)
+		for _, sec := range elfFile.Sections {
+			noBits[sec.Name] = sec.Type == elf.SHT_NOBITS
+		}
 
 		// 获取符号表
-		if elfFile.Symbols != nil {
-			if syms, err := elfFile.Symbols(); err == nil {
-				for _, sym := range syms {
-					if sym.Section >= 0 && int(sym.Section) < len(elfFile.Sections) {
-						section := elfFile.Sections[sym.Section]
-						name := sym.Name
-						if pkg := extractPackageFromSymbol(name); pkg != "" {
-							symbols = append(symbols, Symbol{
-								Name:    name,
-								Size:    sym.Size,
-								Address: sym.Value,
-								Package: pkg,
-								Section: section.Name,
-							})
-						}
+		syms, err := symFile.Symbols()
+		if err != nil || len(syms) == 0 {
+			// 如果没有符号信息，尝试从动态符号表获取
+			syms, _ = elfFile.DynamicSymbols()
+			symFile = elfFile
+		}
+		for _, sym := range syms {
+			// 未定义的符号（SHN_UNDEF）来自共享库，不在这个文件中
+			if sym.Section > elf.SHN_UNDEF && int(sym.Section) < len(symFile.Sections) {
+				section := symFile.Sections[sym.Section]
+				name := sym.Name
+				// .bss/.noptrbss 等节不占文件空间，只计入静态内存
+				if nb, ok := noBits[section.Name]; nb || !ok {
+					if !nb || !memorySections[section.Name] || sym.Size == 0 {
+						continue
+					}
+					if pkg := extractPackageFromSymbol(name); pkg != "" {
+						image.BSS = append(image.BSS, Symbol{Name: name, Size: sym.Size, Address: sym.Value, Package: pkg, Section: section.Name})
+					} else if elf.ST_TYPE(sym.Info) == elf.STT_OBJECT {
+						foreignBSS = append(foreignBSS, Symbol{Name: name, Size: sym.Size, Address: sym.Value, Section: section.Name})
 					}
+					continue
+				}
+				if pkg := extractPackageFromSymbol(name); pkg != "" {
+					image.Symbols = append(image.Symbols, Symbol{
+						Name:    name,
+						Size:    sym.Size,
+						Address: sym.Value,
+						Package: pkg,
+						Section: section.Name,
+					})
+				} else if typ := elf.ST_TYPE(sym.Info); sym.Size > 0 && (typ == elf.STT_FUNC || typ == elf.STT_OBJECT) {
+					foreign = append(foreign, Symbol{Name: name, Size: sym.Size, Address: sym.Value, Section: section.Name})
 				}
 			}
 		}
 
+		image.Strings = elfStringData(symFile, syms)
+
 		// 获取节信息用于后续分析
 		for _, sec := range elfFile.Sections {
-			sections = append(sections, Section{
+			image.Sections = append(image.Sections, Section{
 				Name: sec.Name,
+				Addr: sec.Addr,
 				Size: sec.Size,
 				Type: sec.Type.String(),
 			})
 		}
-
-		// 如果没有符号信息，尝试从动态符号表获取
-		if len(symbols) == 0 && elfFile.DynamicSymbols != nil {
-			if dynSyms, err := elfFile.DynamicSymbols(); err == nil {
-				for _, sym := range dynSyms {
-					if sym.Section >= 0 && int(sym.Section) < len(elfFile.Sections) {
-						section := elfFile.Sections[sym.Section]
-						name := sym.Name
-						if pkg := extractPackageFromSymbol(name); pkg != "" {
-							symbols = append(symbols, Symbol{
-								Name:    name,
-								Size:    sym.Size,
-								Address: sym.Value,
-								Package: pkg,
-								Section: section.Name,
-							})
-						}
-					}
-				}
-			}
-		}
 	} else {
 		// 重置文件指针
 		f.Seek(0, 0)
 
 		// 尝试 Mach-O 格式 (macOS)
 		if machoFile, err := macho.NewFile(f); err == nil {
-			archType = "MachO"
+			image.Format = "MachO"
+			dwarfData = machoFile.DWARF
+			pclntab = func() ([]byte, uint64, error) { return machoPclntab(machoFile) }
+			order = machoFile.ByteOrder
+			image.Libraries, _ = machoFile.ImportedLibraries()
 
-			// Mach-O 符号表处理
+			// Mach-O 符号表不记录大小，按地址差计算
 			if machoFile.Symtab != nil {
-				for _, sym := range machoFile.Symtab.Syms {
-					name := sym.Name
-
-					if pkg := extractPackageFromSymbol(name); pkg != "" {
-						// 尝试估算 Mach-O 符号的大小
-						estimatedSize := estimateMachOSymbolSize(machoFile, sym)
-						symbols = append(symbols, Symbol{
-							Name:    name,
-							Size:    estimatedSize,
-							Address: 0, // Mach-O 符号可能没有地址信息
-							Package: pkg,
-							Section: "", // Mach-O 符号没有直接的节信息
-						})
+				var all []Symbol
+				all, image.Strings = machoSymbols(machoFile)
+				for _, sym := range all {
+					if sym.Package = extractPackageFromSymbol(sym.Name); sym.Package != "" {
+						image.Symbols = append(image.Symbols, sym)
 					}
 				}
 			}
 
 			// 获取 Mach-O 段信息
 			for _, seg := range machoFile.Sections {
-				sections = append(sections, Section{
+				image.Sections = append(image.Sections, Section{
 					Name: seg.Name,
+					Addr: seg.Addr,
 					Size: uint64(seg.Size),
 					Type: "section",
 				})
@@ -134,115 +224,285 @@ func analyzeBinarySymbolTable(binaryPath string) (map[string]uint64, error) {
 
 			// 尝试 PE 格式 (Windows)
 			if peFile, err := pe.NewFile(f); err == nil {
-				archType = "PE"
-
-				// PE 文件符号处理
-				if peFile.Symbols != nil {
-					// PE 符号处理较为复杂，这里简化处理
-					// 获取节信息
-					for _, sec := range peFile.Sections {
-						sections = append(sections, Section{
-							Name: sec.Name,
-							Size: uint64(sec.Size),
-							Type: "section",
-						})
-					}
+				image.Format = "PE"
+				dwarfData = peFile.DWARF
+				pclntab = func() ([]byte, uint64, error) { return pePclntab(peFile) }
+				image.Libraries, _ = peFile.ImportedLibraries()
+
+				// PE 符号表不记录大小，这里只获取节信息，符号大小交给 DWARF 或 pclntab
+				for _, sec := range peFile.Sections {
+					image.Sections = append(image.Sections, Section{
+						Name: sec.Name,
+						Size: uint64(sec.Size),
+						Type: "section",
+					})
 				}
 			}
 		}
 	}
 
-	if len(symbols) == 0 {
-		return nil, fmt.Errorf("no symbols found in %s binary", archType)
+	if image.Format == "" {
+		return nil, fmt.Errorf("%s is not an ELF, Mach-O or PE binary", binaryPath)
 	}
 
-	// 按包聚合符号大小
-	pkgSizes := make(map[string]uint64)
-	for _, sym := range symbols {
-		pkgSizes[sym.Package] += sym.Size
+	// 没有符号表时使用 DWARF 中的函数地址范围
+	if len(image.Symbols) == 0 && dwarfData != nil {
+		if d, err := dwarfData(); err == nil {
+			if symbols := parseDWARF(d); len(symbols) > 0 {
+				image.Symbols = symbols
+				image.Source = SourceDWARF
+			}
+		}
 	}
 
-	// 如果符号大小总和为0，尝试基于符号数量进行粗略估计
-	totalSymbols := len(symbols)
-	if totalSymbols > 0 {
-		// 计算每包符号数量
-		pkgSymbolCounts := make(map[string]int)
-		for _, sym := range symbols {
-			pkgSymbolCounts[sym.Package]++
+	// 剥离了符号表和 DWARF 的二进制文件（-ldflags="-s -w"）仍然保留 pclntab
+	if len(image.Symbols) == 0 && pclntab != nil {
+		if data, textStart, err := pclntab(); err == nil {
+			if symbols := parsePclntab(data, textStart); len(symbols) > 0 {
+				image.Symbols = symbols
+				image.Source = SourcePclntab
+			}
 		}
+	}
 
-		// 如果所有符号大小都是0，根据符号数量分配预估大小
-		if sumMapValues(pkgSizes) == 0 {
-			// 基于二进制文件大小和符号分布估算
-			fileStat, err := os.Stat(binaryPath)
-			if err == nil {
-				totalBinarySize := uint64(fileStat.Size())
-				// 假设代码段占二进制文件的一部分，按符号数量比例分配
-				codeRatio := 0.7 // 假设70%是代码
-				avgSymbolSize := uint64(float64(totalBinarySize) * codeRatio / float64(totalSymbols))
-
-				for pkg, count := range pkgSymbolCounts {
-					pkgSizes[pkg] = uint64(count) * avgSymbolSize
-				}
+	if len(image.Symbols) == 0 {
+		return nil, fmt.Errorf("no symbols found in %s binary", image.Format)
+	}
+
+	// 较新的链接器不再为每个类型描述符输出符号，从 DWARF 中找回它们
+	if dwarfData != nil && !hasSizedTypes(image.Symbols) {
+		if start, end, section, ok := image.typesRegion(); ok {
+			if d, err := dwarfData(); err == nil {
+				image.Symbols = append(image.Symbols, typeSymbols(d, start, end, section)...)
 			}
 		}
 	}
+	// C 符号按 DWARF 编译单元归属到拥有 cgo 代码的包或 <cgo>
+	if len(foreign) > 0 {
+		var d *dwarf.Data
+		if dwarfData != nil {
+			d, _ = dwarfData()
+		}
+		image.Symbols = append(image.Symbols, cgoSymbols(foreign, image.Symbols, d, order)...)
+	}
+	if len(foreignBSS) > 0 {
+		var d *dwarf.Data
+		if dwarfData != nil {
+			d, _ = dwarfData()
+		}
+		image.BSS = append(image.BSS, cgoSymbols(foreignBSS, image.Symbols, d, order)...)
+	}
+	// PIE、c-shared 和 plugin 的动态重定位表按被重定位的数据归属
+	if relocations != nil {
+		image.Symbols = append(image.Symbols, relocations(image.Symbols)...)
+	}
+	for i := range image.Symbols {
+		sym := &image.Symbols[i]
+		sym.Kind = symbolKind(sym.Name, sym.Section)
+		sym.Generic = genericOrigin(sym.Name, sym.Kind)
+	}
+	if logical {
+		var d *dwarf.Data
+		if dwarfData != nil {
+			d, _ = dwarfData()
+		}
+		// 没有 DWARF 时保持物理视图，由调用方提示
+		if d != nil {
+			image.Symbols, image.Inlined = inlineSymbols(d, image.Symbols)
+			image.Logical = true
+		}
+	}
 
-	return pkgSizes, nil
+	return image, nil
 }
 
-// estimateMachOSymbolSize 尝试估算 Mach-O 符号的大小
-func estimateMachOSymbolSize(file *macho.File, sym macho.Symbol) uint64 {
-	// Mach-O 符号本身不包含大小信息，但我们可以通过地址差来估算
-	// 这是一个简化的估算方法
-	return 100 // 返回一个默认估算值，实际实现需要更复杂的算法
-}
+// machoSymbols 读出 Mach-O 符号表中定义在占文件空间的节里的符号，以及字符串字面量占用的字节数
+// 每个符号的大小取到同一节中下一个地址更大的符号为止，最后一个取到节的末尾；
+// 同一地址上的多个符号只有第一个（按名字排序）得到大小；链接器的边界标记排在最后，只作为边界，大小为 0
+func machoSymbols(f *macho.File) ([]Symbol, uint64) {
+	const (
+		nStab                = 0xe0 // 调试符号
+		nType                = 0x0e
+		nSect                = 0x0e // 定义在某个节中
+		sZerofill            = 0x1  // __bss 等不占文件空间的节
+		sGBZerofill          = 0xc
+		sThreadLocalZerofill = 0x12
+	)
+	bySection := make(map[uint8][]Symbol)
+	markers := make(map[string]bool)
+	for _, sym := range f.Symtab.Syms {
+		if sym.Type&nStab != 0 || sym.Type&nType != nSect || sym.Sect == 0 || int(sym.Sect) > len(f.Sections) {
+			continue
+		}
+		switch f.Sections[sym.Sect-1].Flags & 0xff {
+		case sZerofill, sGBZerofill, sThreadLocalZerofill:
+			continue
+		}
+		bySection[sym.Sect] = append(bySection[sym.Sect], Symbol{Name: sym.Name, Address: sym.Value, Section: f.Sections[sym.Sect-1].Name})
+		if linkerMarker.MatchString(sym.Name) {
+			markers[sym.Name] = true
+		}
+	}
 
-// sumMapValues 计算映射中所有值的总和
-func sumMapValues(m map[string]uint64) uint64 {
-	var sum uint64
-	for _, v := range m {
-		sum += v
+	var symbols []Symbol
+	var stringData uint64
+	for sect, syms := range bySection {
+		sec := f.Sections[sect-1]
+		end := sec.Addr + sec.Size
+		sort.Slice(syms, func(i, j int) bool {
+			if syms[i].Address != syms[j].Address {
+				return syms[i].Address < syms[j].Address
+			}
+			if markers[syms[i].Name] != markers[syms[j].Name] {
+				return markers[syms[j].Name]
+			}
+			return syms[i].Name < syms[j].Name
+		})
+		for i := range syms {
+			next := end
+			for j := i + 1; j < len(syms); j++ {
+				if syms[j].Address > syms[i].Address {
+					next = syms[j].Address
+					break
+				}
+			}
+			if (i > 0 && syms[i-1].Address == syms[i].Address) || syms[i].Address >= next || markers[syms[i].Name] {
+				continue
+			}
+			syms[i].Size = next - syms[i].Address
+			if syms[i].Name == "go:string.*" {
+				stringData = syms[i].Size
+			}
+		}
+		symbols = append(symbols, syms...)
 	}
-	return sum
+	return symbols, stringData
 }
 
 // extractPackageFromSymbol 从符号名中提取包名
 func extractPackageFromSymbol(symbolName string) string {
 	// Go 符号通常以包路径开头
-	// 例如 runtime·xxx 或 main.xxx 或 github.com/user/repo/pkg.funcName
+	// 例如 runtime.xxx、main.xxx、fmt.Println 或 github.com/user/repo/pkg.(*T).Method
+	// 链接器会把包路径最后一段中的 "." 转义为 "%2e"（如 gopkg.in/yaml%2ev3.Marshal）
 
-	// 查找第一个点或中间的包分隔符
-	if idx := strings.Index(symbolName, "."); idx > 0 {
-		prefix := symbolName[:idx]
+	// 类型描述符归属定义类型的包，itab 归属具体类型所在的包
+	if name, ok := strings.CutPrefix(symbolName, "type:"); ok {
+		return typePackage(name)
+	}
+	if name, ok := strings.CutPrefix(symbolName, "go:itab."); ok {
+		concrete, _, _ := strings.Cut(name, ",")
+		return typePackage(concrete)
+	}
 
-		// 处理运行时符号
-		if prefix == "runtime" || prefix == "main" || prefix == "go" {
-			return prefix
-		}
+	// 方法接收者和泛型参数中可能出现 "/"，只在它们之前查找包路径
+	end := len(symbolName)
+	if idx := strings.IndexAny(symbolName, "[("); idx >= 0 {
+		end = idx
+	}
+	slash := strings.LastIndex(symbolName[:end], "/")
+	dot := strings.Index(symbolName[slash+1:end], ".")
+	if dot <= 0 {
+		return ""
+	}
+	prefix := symbolName[:slash+1+dot]
 
-		// 检查是否是有效的包路径（包含域名或常见模式）
-		if strings.Contains(prefix, "/") || strings.Contains(prefix, ".") {
-			// 提取完整的包路径
-			// 移除符号名部分，保留包路径
-			parts := strings.Split(symbolName, ".")
-			if len(parts) > 1 {
-				// 重构包路径部分
-				for i := len(parts) - 1; i >= 0; i-- {
-					packageName := strings.Join(parts[:i], ".")
-					// 检查是否看起来像一个包路径
-					if strings.Contains(packageName, "/") || packageName == "main" || packageName == "runtime" {
-						return packageName
-					}
-				}
+	// go:string.* 等其他链接器生成的符号以及 C 符号（包括 setenv@GLIBC_2.2.5 这样带版本的）不属于任何包
+	if strings.ContainsAny(prefix, ":$ @") || strings.HasPrefix(prefix, "_") {
+		return ""
+	}
+	// 没有路径的名字后面跟着 GCC 生成的后缀时是 C 符号，不是包名
+	if slash < 0 && gccCloneSuffix.MatchString(symbolName[len(prefix):]) {
+		return ""
+	}
+
+	return strings.ReplaceAll(prefix, "%2e", ".")
+}
+
+// elfStringData 返回 ELF 文件中字符串字面量占用的字节数
+func elfStringData(f *elf.File, syms []elf.Symbol) uint64 {
+	for _, sym := range syms {
+		if sym.Name != "go:string.*" || sym.Section < 0 || int(sym.Section) >= len(f.Sections) {
+			continue
+		}
+		section := f.Sections[sym.Section]
+		var addrs []uint64
+		for _, other := range syms {
+			if other.Section == sym.Section {
+				addrs = append(addrs, other.Value)
 			}
 		}
+		return stringDataSize(sym.Value, section.Addr+section.Size, addrs)
 	}
+	return 0
+}
 
-	return ""
+// stringDataSize 返回大小为 0 的 go:string.* 容器符号覆盖的字节数：
+// 链接器把所有字符串字面量放在它之后，直到同一节中下一个地址更大的符号或节的末尾
+func stringDataSize(start, sectionEnd uint64, addrs []uint64) uint64 {
+	end := sectionEnd
+	for _, addr := range addrs {
+		if addr > start && addr < end {
+			end = addr
+		}
+	}
+	if end < start {
+		return 0
+	}
+	return end - start
 }
 
-// parseDWARF 从 DWARF 调试信息中提取符号
+// symbolKind 根据符号名和所在的节判断符号的种类
+func symbolKind(name, section string) string {
+	switch {
+	case strings.HasPrefix(name, "type:"):
+		return KindType
+	case strings.HasPrefix(name, "go:itab."):
+		return KindItab
+	case strings.HasPrefix(name, "go:string."):
+		return KindString
+	case strings.HasPrefix(name, "go:rela."):
+		return KindReloc
+	case section == ".text" || section == "__text" || section == "code":
+		if closureSuffix.MatchString(name) {
+			return KindClosure
+		}
+		return KindFunc
+	default:
+		return KindData
+	}
+}
+
+// genericOrigin 去掉泛型实例化的函数符号中的类型参数，返回原始函数名；不是泛型实例时返回空字符串
+// 例如 slices.Sort[go.shape.int] -> slices.Sort，pkg.(*List[go.shape.string]).Push -> pkg.(*List).Push，
+// 字典 pkg..dict.Map[int,string] -> pkg.Map。kind 是符号的种类，只有函数、闭包、内联代码和字典是实例化的结果，
+// 类型描述符（type:pkg.List[int]）和 itab 的名字里虽然也有类型参数，但不属于任何泛型函数
+func genericOrigin(symbolName, kind string) string {
+	if !strings.Contains(symbolName, "[") || strings.HasPrefix(symbolName, "type:") || strings.HasPrefix(symbolName, "go:itab.") {
+		return ""
+	}
+	switch kind {
+	case KindFunc, KindClosure, KindInline:
+	default:
+		if !strings.Contains(symbolName, "..dict.") {
+			return ""
+		}
+	}
+	var b strings.Builder
+	depth := 0
+	for _, c := range symbolName {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+	return strings.Replace(b.String(), "..dict.", ".", 1)
+}
+
+// parseDWARF 从 DWARF 调试信息中提取函数符号，大小取自函数的地址范围
 func parseDWARF(dwarfData *dwarf.Data) []Symbol {
 	var symbols []Symbol
 	r := dwarfData.Reader()
@@ -258,11 +518,117 @@ func parseDWARF(dwarfData *dwarf.Data) []Symbol {
 			if ok {
 				if pkg := extractPackageFromSymbol(name); pkg != "" {
 					// 从 DWARF 信息中获取更多细节
-					symbols = append(symbols, Symbol{Name: name, Size: 0, Package: pkg})
+					var size, address uint64
+					if ranges, err := dwarfData.Ranges(entry); err == nil {
+						for _, rng := range ranges {
+							if address == 0 {
+								address = rng[0]
+							}
+							size += rng[1] - rng[0]
+						}
+					}
+					if size > 0 {
+						symbols = append(symbols, Symbol{Name: name, Size: size, Address: address, Package: pkg, Section: ".text"})
+					}
 				}
 			}
+			r.SkipChildren()
 		}
 	}
 
 	return symbols
-}
\ No newline at end of file
+}
+
+// parsePclntab 从 pclntab 函数表中提取函数符号，大小为函数入口到结束地址的距离
+func parsePclntab(data []byte, textStart uint64) []Symbol {
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(data, textStart))
+	if err != nil {
+		return nil
+	}
+
+	var symbols []Symbol
+	for _, fn := range table.Funcs {
+		if pkg := extractPackageFromSymbol(fn.Name); pkg != "" && fn.End > fn.Entry {
+			symbols = append(symbols, Symbol{
+				Name:    fn.Name,
+				Size:    fn.End - fn.Entry,
+				Address: fn.Entry,
+				Package: pkg,
+				Section: ".text",
+			})
+		}
+	}
+	return symbols
+}
+
+// elfPclntab 返回 ELF 文件中的 pclntab 数据和代码段起始地址
+func elfPclntab(f *elf.File) ([]byte, uint64, error) {
+	sec := f.Section(".gopclntab")
+	if sec == nil {
+		// 部分构建模式（如 PIE）把 pclntab 放在 .data.rel.ro 中，只能靠符号定位
+		return nil, 0, fmt.Errorf("no .gopclntab section")
+	}
+	data, err := sec.Data()
+	if err != nil {
+		return nil, 0, err
+	}
+	var textStart uint64
+	if text := f.Section(".text"); text != nil {
+		textStart = text.Addr
+	}
+	return data, textStart, nil
+}
+
+// machoPclntab 返回 Mach-O 文件中的 pclntab 数据和代码段起始地址
+func machoPclntab(f *macho.File) ([]byte, uint64, error) {
+	sec := f.Section("__gopclntab")
+	if sec == nil {
+		return nil, 0, fmt.Errorf("no __gopclntab section")
+	}
+	data, err := sec.Data()
+	if err != nil {
+		return nil, 0, err
+	}
+	var textStart uint64
+	if text := f.Section("__text"); text != nil {
+		textStart = text.Addr
+	}
+	return data, textStart, nil
+}
+
+// pePclntab 通过 runtime.pclntab/runtime.epclntab 符号定位 PE 文件中的 pclntab
+func pePclntab(f *pe.File) ([]byte, uint64, error) {
+	var start, end *pe.Symbol
+	for _, sym := range f.Symbols {
+		switch sym.Name {
+		case "runtime.pclntab":
+			start = sym
+		case "runtime.epclntab":
+			end = sym
+		}
+	}
+	if start == nil || end == nil || start.SectionNumber != end.SectionNumber || start.SectionNumber <= 0 {
+		return nil, 0, fmt.Errorf("no runtime.pclntab symbol")
+	}
+	sec := f.Sections[start.SectionNumber-1]
+	data, err := sec.Data()
+	if err != nil {
+		return nil, 0, err
+	}
+	if end.Value > uint32(len(data)) || start.Value > end.Value {
+		return nil, 0, fmt.Errorf("runtime.pclntab out of range")
+	}
+
+	var imageBase uint64
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		imageBase = uint64(oh.ImageBase)
+	case *pe.OptionalHeader64:
+		imageBase = oh.ImageBase
+	}
+	var textStart uint64
+	if text := f.Section(".text"); text != nil {
+		textStart = imageBase + uint64(text.VirtualAddress)
+	}
+	return data[start.Value:end.Value], textStart, nil
+}
@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"debug/macho"
+	"testing"
+)
+
+func TestExtractPackageFromSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   string
+	}{
+		{"runtime.main", "runtime"},
+		{"main.main", "main"},
+		{"fmt.Println", "fmt"},
+		{"strings.(*Builder).WriteString", "strings"},
+		{"encoding/json.Marshal", "encoding/json"},
+		{"github.com/a/b.F", "github.com/a/b"},
+		{"github.com/a/b.(*T).M", "github.com/a/b"},
+		{"github.com/a/b.F.func1", "github.com/a/b"},
+		{"gopkg.in/yaml%2ev3.Marshal", "gopkg.in/yaml.v3"},
+		{"slices.Sort[go.shape.*github.com/x/y.T]", "slices"},
+		{"github.com/a/b.(*List[go.shape.string]).Push", "github.com/a/b"},
+		{"go:string.\"a/b.c\"", ""},
+		{"_cgo_init", ""},
+		{"x_cgo_init", ""},
+		{"x_cgo_munmap.cold", ""},
+		{"deflate.part.0", ""},
+		{"completed.0", ""},
+		{"setenv@GLIBC_2.2.5", ""},
+		{"inflate_fast.constprop.0.isra.0", ""},
+		{"github.com/a/b.cold", "github.com/a/b"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := extractPackageFromSymbol(tt.symbol); got != tt.want {
+			t.Errorf("extractPackageFromSymbol(%q) = %q, want %q", tt.symbol, got, tt.want)
+		}
+	}
+}
+
+func TestGenericOrigin(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		want string
+	}{
+		{"slices.Sort[go.shape.int]", KindFunc, "slices.Sort"},
+		{"slices.SortFunc[go.shape.[]string,go.shape.string]", KindFunc, "slices.SortFunc"},
+		{"github.com/a/b.(*List[go.shape.string]).Push", KindFunc, "github.com/a/b.(*List).Push"},
+		{"github.com/a/b.Map[go.shape.int,go.shape.string].func1", KindClosure, "github.com/a/b.Map.func1"},
+		{"github.com/a/b.Map[go.shape.int]", KindInline, "github.com/a/b.Map"},
+		{"github.com/a/b..dict.Map[int,string]", KindData, "github.com/a/b.Map"},
+		{"fmt.Println", KindFunc, ""},
+		// 类型描述符和 itab 的名字里有类型参数，但不是泛型函数
+		{"type:github.com/a/b.List[int]", KindType, ""},
+		{"type:*github.com/a/b.List[go.shape.string]", KindType, ""},
+		{"type:map[string]int", KindType, ""},
+		{"type:..eq.[2]interface {}", KindFunc, ""},
+		{"go:itab.*github.com/a/b.List[int],io.Writer", KindItab, ""},
+		{`go:string."[x]"`, KindString, ""},
+		{"github.com/a/b.table[int]", KindData, ""},
+	}
+	for _, tt := range tests {
+		if got := genericOrigin(tt.name, tt.kind); got != tt.want {
+			t.Errorf("genericOrigin(%q, %s) = %q, want %q", tt.name, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestMachOSymbols(t *testing.T) {
+	section := func(name string, addr, size uint64, flags uint32) *macho.Section {
+		return &macho.Section{SectionHeader: macho.SectionHeader{Name: name, Addr: addr, Size: size, Flags: flags}}
+	}
+	f := &macho.File{
+		Sections: []*macho.Section{
+			section("__text", 0x1000, 0x100, 0),
+			section("__rodata", 0x2000, 0x80, 0),
+			section("__bss", 0x3000, 0x40, 1),
+		},
+		Symtab: &macho.Symtab{Syms: []macho.Symbol{
+			{Name: "runtime.text", Type: 0x0e, Sect: 1, Value: 0x1000},
+			{Name: "main.main", Type: 0x0e, Sect: 1, Value: 0x1000},
+			{Name: "main.alias", Type: 0x0f, Sect: 1, Value: 0x1000},
+			{Name: "main.f", Type: 0x0f, Sect: 1, Value: 0x1030},
+			{Name: "runtime.etext", Type: 0x0e, Sect: 1, Value: 0x10f0},
+			{Name: "go:string.*", Type: 0x0e, Sect: 2, Value: 0x2000},
+			{Name: "runtime.rodata", Type: 0x0e, Sect: 2, Value: 0x2000},
+			{Name: "main.table", Type: 0x0e, Sect: 2, Value: 0x2050},
+			{Name: "main.zero", Type: 0x0e, Sect: 3, Value: 0x3000},
+			{Name: "_malloc", Type: 0x01, Sect: 0},
+			{Name: "main.go", Type: 0x64, Sect: 1, Value: 0x1000},
+		}},
+	}
+
+	want := map[string]uint64{
+		"main.alias":     0x30,
+		"main.main":      0,
+		"runtime.text":   0,
+		"main.f":         0xc0,
+		"runtime.etext":  0,
+		"go:string.*":    0x50,
+		"runtime.rodata": 0,
+		"main.table":     0x30,
+	}
+	symbols, strings := machoSymbols(f)
+	if len(symbols) != len(want) {
+		t.Errorf("got %d symbols, want %d: %+v", len(symbols), len(want), symbols)
+	}
+	for _, sym := range symbols {
+		if size, ok := want[sym.Name]; !ok || sym.Size != size {
+			t.Errorf("%s: size %#x, want %#x", sym.Name, sym.Size, size)
+		}
+	}
+	if strings != 0x50 {
+		t.Errorf("strings = %#x, want 0x50", strings)
+	}
+}
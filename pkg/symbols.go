@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dustin/go-humanize"
+)
+
+// SymbolEntry 是符号列表中的一行，附带符号所属的包和模块
+type SymbolEntry struct {
+	*Symbol
+	Package   string `json:"package"`
+	Module    string `json:"module"`
+	SizeHuman string `json:"size_human"`
+}
+
+// TopSymbols 返回报告中最大的 n 个符号（函数和数据），n <= 0 时返回全部
+// 报告必须包含符号（没有经过 WithoutSymbols）
+func (r *Report) TopSymbols(n int) []*SymbolEntry {
+	var entries []*SymbolEntry
+	for _, module := range r.Modules {
+		for _, p := range module.Packages {
+			entries = appendSymbolEntries(entries, module, p)
+		}
+	}
+	sortSymbolEntries(entries)
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// PackageSymbols 返回一个包中的所有符号，按大小降序排列
+func (r *Report) PackageSymbols(path string) ([]*SymbolEntry, error) {
+	for _, module := range r.Modules {
+		for _, p := range module.Packages {
+			if p.Path == path {
+				entries := appendSymbolEntries(nil, module, p)
+				sortSymbolEntries(entries)
+				return entries, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("package %s has no symbols in %s", path, r.Binary.Path)
+}
+
+func appendSymbolEntries(entries []*SymbolEntry, module *ModuleReport, p *PackageReport) []*SymbolEntry {
+	for _, sym := range p.Symbols {
+		entries = append(entries, &SymbolEntry{
+			Symbol:    sym,
+			Package:   p.Path,
+			Module:    module.Path,
+			SizeHuman: humanize.Bytes(sym.Size),
+		})
+	}
+	return entries
+}
+
+func sortSymbolEntries(entries []*SymbolEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
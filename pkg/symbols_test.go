@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestSymbolKind(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		want    string
+	}{
+		{"main.main", ".text", KindFunc},
+		{"main.main", "__text", KindFunc},
+		{"main.main", "code", KindFunc},
+		{"main.main.func1", ".text", KindClosure},
+		{"main.main.func1.2", ".text", KindClosure},
+		{"main.run.gowrap1", ".text", KindClosure},
+		{"main.run.deferwrap2", ".text", KindClosure},
+		{"main.funcs", ".text", KindFunc},
+		{"type:*main.T", ".rodata", KindType},
+		{"go:itab.*os.File,io.Writer", ".rodata", KindItab},
+		{`go:string."hello"`, ".rodata", KindString},
+		{"go:rela.data", ".rela", KindReloc},
+		{"main.table", ".noptrdata", KindData},
+	}
+	for _, tt := range tests {
+		if got := symbolKind(tt.name, tt.section); got != tt.want {
+			t.Errorf("symbolKind(%q, %q) = %q, want %q", tt.name, tt.section, got, tt.want)
+		}
+	}
+}
+
+func TestTopSymbols(t *testing.T) {
+	report := &Report{Modules: []*ModuleReport{
+		{Path: "example.com/app", Packages: []*PackageReport{
+			{Path: "example.com/app", Symbols: []*Symbol{{Name: "main.b", Size: 30}, {Name: "main.a", Size: 30}}},
+		}},
+		{Path: StdModule, Packages: []*PackageReport{
+			{Path: "fmt", Symbols: []*Symbol{{Name: "fmt.Println", Size: 100}}},
+			{Path: "errors", Symbols: []*Symbol{{Name: "errors.New", Size: 10}}},
+		}},
+	}}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{2, []string{"fmt.Println", "main.a"}},
+		{0, []string{"fmt.Println", "main.a", "main.b", "errors.New"}},
+		{10, []string{"fmt.Println", "main.a", "main.b", "errors.New"}},
+	}
+	for _, tt := range tests {
+		entries := report.TopSymbols(tt.n)
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("TopSymbols(%d) = %v, want %v", tt.n, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("TopSymbols(%d) = %v, want %v", tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+
+	entries, err := report.PackageSymbols("fmt")
+	if err != nil || len(entries) != 1 || entries[0].Module != StdModule || entries[0].SizeHuman != "100 B" {
+		t.Errorf("PackageSymbols(fmt) = %v, %v", entries, err)
+	}
+	if _, err := report.PackageSymbols("net/http"); err == nil {
+		t.Error("PackageSymbols of a missing package did not fail")
+	}
+}
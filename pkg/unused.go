@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"sort"
+
+	"github.com/dustin/go-humanize"
+)
+
+// go.mod 中可以清理的依赖的原因
+const (
+	UnusedNotBuilt      = "not-built"      // 不在构建中（只被测试或工具使用）
+	UnusedNotLinked     = "not-linked"     // 参与了构建，但没有任何字节被链接
+	UnusedSinglePackage = "single-package" // 只链接了一个很小的包
+	UnusedSmall         = "small"          // 链接的大小低于阈值
+)
+
+// UnusedRequirement 是一个对二进制文件几乎没有贡献的 go.mod 依赖
+type UnusedRequirement struct {
+	Requirement
+	Reason    string `json:"reason"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+	// Package 是 single-package 时唯一链接的包
+	Package string `json:"package,omitempty"`
+}
+
+// UnusedRequirements 把 go.mod 的依赖和 buildinfo 中的模块以及实际链接的包对照，
+// 找出没有参与构建、没有被链接、或链接的大小低于 threshold 的依赖
+func (r *Report) UnusedRequirements(requirements []Requirement, threshold uint64) []*UnusedRequirement {
+	modules := make(map[string]*ModuleReport)
+	for _, m := range r.Modules {
+		modules[m.Path] = m
+	}
+
+	var result []*UnusedRequirement
+	for _, req := range requirements {
+		u := &UnusedRequirement{Requirement: req}
+		m, built := modules[req.Path]
+		switch {
+		case !built:
+			u.Reason = UnusedNotBuilt
+		case m.Size == 0:
+			u.Reason = UnusedNotLinked
+		case m.Size >= threshold:
+			continue
+		case len(m.Packages) == 1:
+			u.Reason = UnusedSinglePackage
+			u.Package = m.Packages[0].Path
+		default:
+			u.Reason = UnusedSmall
+		}
+		if built {
+			u.Size = m.Size
+		}
+		u.SizeHuman = humanize.Bytes(u.Size)
+		result = append(result, u)
+	}
+
+	order := map[string]int{UnusedNotBuilt: 0, UnusedNotLinked: 1, UnusedSinglePackage: 2, UnusedSmall: 3}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Reason != result[j].Reason {
+			return order[result[i].Reason] < order[result[j].Reason]
+		}
+		if result[i].Size != result[j].Size {
+			return result[i].Size < result[j].Size
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
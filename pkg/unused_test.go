@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestUnusedRequirements(t *testing.T) {
+	report := &Report{Modules: []*ModuleReport{
+		{Path: "github.com/big/lib", Size: 50000, Packages: []*PackageReport{{Path: "github.com/big/lib"}, {Path: "github.com/big/lib/sub"}}},
+		{Path: "github.com/thoas/go-funk", Size: 0},
+		{Path: "golang.org/x/sys", Size: 1100, Packages: []*PackageReport{{Path: "golang.org/x/sys/unix", Size: 1100}}},
+		{Path: "github.com/small/two", Size: 2000, Packages: []*PackageReport{{Path: "github.com/small/two"}, {Path: "github.com/small/two/b"}}},
+	}}
+	requirements := []Requirement{
+		{Path: "github.com/big/lib", Version: "v1.0.0"},
+		{Path: "github.com/thoas/go-funk", Version: "v0.9.3"},
+		{Path: "golang.org/x/sys", Version: "v0.33.0", Indirect: true},
+		{Path: "github.com/small/two", Version: "v1.0.0"},
+		{Path: "github.com/stretchr/testify", Version: "v1.9.0"},
+	}
+
+	tests := []struct {
+		path    string
+		reason  string
+		size    uint64
+		pkgPath string
+	}{
+		{"github.com/stretchr/testify", UnusedNotBuilt, 0, ""},
+		{"github.com/thoas/go-funk", UnusedNotLinked, 0, ""},
+		{"golang.org/x/sys", UnusedSinglePackage, 1100, "golang.org/x/sys/unix"},
+		{"github.com/small/two", UnusedSmall, 2000, ""},
+	}
+	result := report.UnusedRequirements(requirements, 10000)
+	if len(result) != len(tests) {
+		t.Fatalf("got %d unused requirements, want %d", len(result), len(tests))
+	}
+	for i, tt := range tests {
+		u := result[i]
+		if u.Path != tt.path || u.Reason != tt.reason || u.Size != tt.size || u.Package != tt.pkgPath {
+			t.Errorf("result %d = %s %s %d %q, want %s %s %d %q", i, u.Path, u.Reason, u.Size, u.Package, tt.path, tt.reason, tt.size, tt.pkgPath)
+		}
+	}
+	if !result[2].Indirect {
+		t.Error("indirect marker was lost")
+	}
+}
@@ -0,0 +1,413 @@
+package pkg
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/gosym"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"sort"
+)
+
+// SourceWasm 表示函数大小取自 WebAssembly 代码段，函数名取自 name 自定义段和 pclntab
+const SourceWasm = "wasm"
+
+const wasmMagic = "\x00asm"
+
+// WebAssembly 段的编号
+const (
+	wasmSectionCustom = 0
+	wasmSectionImport = 2
+	wasmSectionCode   = 10
+	wasmSectionData   = 11
+)
+
+// wasmNameMangle 是 Go 链接器写入 name 段时替换成 "_" 的字符（cmd/link/internal/wasm 中的 nameRegexp）
+var wasmNameMangle = regexp.MustCompile(`[^\w.]`)
+
+// 模块信息前后的哨兵，见 cmd/go/internal/modload
+var (
+	modInfoStart = []byte("\x30\x77\xaf\x0c\x92\x74\x08\x02\x41\xe1\xc1\x07\xe6\xd6\x18\xe6")
+	modInfoEnd   = []byte("\xf9\x32\x43\x31\x86\x18\x20\x72\x00\x82\x42\x10\x41\x16\xd8\xf2")
+)
+
+// DataSegments 汇总 WebAssembly 模块的数据段；数据段没有符号，不能归属到包
+type DataSegments struct {
+	Count     int    `json:"count"`
+	Size      uint64 `json:"size"`
+	SizeHuman string `json:"size_human"`
+}
+
+// wasmModule 是解析出的 WebAssembly 模块中 goweight 用到的部分
+type wasmModule struct {
+	imports  int            // 导入的函数数量，定义的函数编号从它开始
+	names    map[int]string // name 段中的函数名
+	bodies   [][2]uint64    // 代码段中每个函数体的文件偏移和大小
+	segments []wasmSegment
+	custom   map[string][]byte
+}
+
+type wasmSegment struct {
+	addr uint64
+	data []byte
+}
+
+// isWasm 判断文件是不是 WebAssembly 模块
+func isWasm(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(wasmMagic))
+	_, err = f.ReadAt(head, 0)
+	return err == nil && string(head) == wasmMagic
+}
+
+// parseWasm 解析 WebAssembly 模块的导入段、代码段、数据段和自定义段
+func parseWasm(data []byte) (*wasmModule, error) {
+	if len(data) < 8 || string(data[:4]) != wasmMagic {
+		return nil, fmt.Errorf("not a WebAssembly module")
+	}
+	m := &wasmModule{names: make(map[int]string), custom: make(map[string][]byte)}
+	r := &wasmReader{data: data, off: 8}
+	for r.err == nil && r.off < len(data) {
+		id := r.byte()
+		size := r.uleb()
+		start := r.off
+		body := r.bytes(size)
+		if r.err != nil {
+			break
+		}
+		sec := &wasmReader{data: data[:start+len(body)], off: start}
+		switch id {
+		case wasmSectionCustom:
+			name := sec.name()
+			m.custom[name] = data[sec.off : start+len(body)]
+		case wasmSectionImport:
+			m.imports = sec.funcImports()
+		case wasmSectionCode:
+			for n := sec.uleb(); n > 0 && sec.err == nil; n-- {
+				size := sec.uleb()
+				m.bodies = append(m.bodies, [2]uint64{uint64(sec.off), size})
+				sec.bytes(size)
+			}
+		case wasmSectionData:
+			m.segments = sec.segments()
+		}
+		if sec.err != nil {
+			return nil, fmt.Errorf("malformed WebAssembly section %d: %w", id, sec.err)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if names, ok := m.custom["name"]; ok {
+		m.readNames(names)
+	}
+	return m, nil
+}
+
+// readNames 读取 name 段中的函数名子段（编号 1）
+func (m *wasmModule) readNames(data []byte) {
+	r := &wasmReader{data: data}
+	for r.err == nil && r.off < len(data) {
+		id := r.byte()
+		sub := r.bytes(r.uleb())
+		if id != 1 || r.err != nil {
+			continue
+		}
+		s := &wasmReader{data: sub}
+		for n := s.uleb(); n > 0 && s.err == nil; n-- {
+			idx := s.uleb()
+			m.names[int(idx)] = s.name()
+		}
+	}
+}
+
+// memory 把数据段按地址拼成线性内存初始内容中连续的几块
+//
+// Go 链接器省去了数据中较长的零序列，所以相邻数据段之间的间隔要补零才能还原 pclntab 等结构；
+// 数据段地址来自文件本身，补零的总量不超过数据段的总大小，间隔更大的数据段另起一块
+func (m *wasmModule) memory() [][]byte {
+	segments := slices.Clone(m.segments)
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].addr < segments[j].addr })
+	var budget uint64
+	for _, seg := range segments {
+		budget += uint64(len(seg.data))
+	}
+
+	var blocks [][]byte
+	var block []byte
+	var start uint64
+	for _, seg := range segments {
+		end := start + uint64(len(block))
+		if block == nil || seg.addr > end && seg.addr-end > budget {
+			if block != nil {
+				blocks = append(blocks, block)
+			}
+			block, start = slices.Clone(seg.data), seg.addr
+			continue
+		}
+		if seg.addr > end {
+			budget -= seg.addr - end
+		}
+		off := seg.addr - start
+		if n := off + uint64(len(seg.data)); n > uint64(len(block)) {
+			block = append(block, make([]byte, n-uint64(len(block)))...)
+		}
+		copy(block[off:], seg.data)
+	}
+	if block != nil {
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// goVersion 返回 producers 段中记录的 Go 版本
+func (m *wasmModule) goVersion() string {
+	r := &wasmReader{data: m.custom["producers"]}
+	for fields := r.uleb(); fields > 0 && r.err == nil; fields-- {
+		field := r.name()
+		for values := r.uleb(); values > 0 && r.err == nil; values-- {
+			name, version := r.name(), r.name()
+			if field == "language" && name == "Go" {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+// readWasmSymbols 把代码段中的每个函数体作为一个符号
+// name 段中的函数名把 "/" 等字符替换成了 "_"，能在 pclntab 中找到时换回原来的名字
+func readWasmSymbols(binaryPath string) (*binaryImage, error) {
+	data, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseWasm(data)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &binaryImage{Format: "wasm", Source: SourceWasm}
+	realNames := make(map[string]string)
+	for _, fn := range wasmPclntabFuncs(m.memory()) {
+		mangled := wasmNameMangle.ReplaceAllString(fn.Name, "_")
+		if _, dup := realNames[mangled]; dup {
+			realNames[mangled] = "" // 有歧义的不还原
+			continue
+		}
+		realNames[mangled] = fn.Name
+	}
+
+	for i, body := range m.bodies {
+		name := m.names[m.imports+i]
+		if real := realNames[name]; real != "" {
+			name = real
+		}
+		if pkg := extractPackageFromSymbol(name); pkg != "" {
+			image.Symbols = append(image.Symbols, Symbol{
+				Name:    name,
+				Size:    body[1],
+				Address: body[0],
+				Package: pkg,
+				Section: "code",
+			})
+		}
+	}
+	if len(image.Symbols) == 0 {
+		return nil, fmt.Errorf("no function names found in WebAssembly module %s", binaryPath)
+	}
+
+	var code uint64
+	for _, body := range m.bodies {
+		code += body[1]
+	}
+	image.Sections = append(image.Sections, Section{Name: "code", Size: code, Type: "section"})
+	image.DataSegments = &DataSegments{Count: len(m.segments)}
+	for _, seg := range m.segments {
+		image.DataSegments.Size += uint64(len(seg.data))
+	}
+	for i := range image.Symbols {
+		sym := &image.Symbols[i]
+		sym.Kind = symbolKind(sym.Name, sym.Section)
+		sym.Generic = genericOrigin(sym.Name, sym.Kind)
+	}
+	return image, nil
+}
+
+// wasmPclntabFuncs 在线性内存中查找 pclntab（Go 1.18 和 1.20 起的魔数，最小指令单位 1，指针 8 字节）并读出函数
+func wasmPclntabFuncs(blocks [][]byte) []gosym.Func {
+	for _, magic := range [][]byte{{0xf1, 0xff, 0xff, 0xff, 0, 0, 1, 8}, {0xf0, 0xff, 0xff, 0xff, 0, 0, 1, 8}} {
+		for _, mem := range blocks {
+			i := bytes.Index(mem, magic)
+			if i < 0 {
+				continue
+			}
+			if table, err := gosym.NewTable(nil, gosym.NewLineTable(mem[i:], 0)); err == nil {
+				return table.Funcs
+			}
+		}
+	}
+	return nil
+}
+
+// readWasmBuildInfo 读取 WebAssembly 模块中的构建信息
+// debug/buildinfo 不支持 wasm，模块信息作为字符串数据保存在数据段中，Go 版本取自 producers 段
+func readWasmBuildInfo(binaryPath string) (*buildinfo.BuildInfo, error) {
+	data, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseWasm(data)
+	if err != nil {
+		return nil, err
+	}
+	var mod []byte
+	for _, mem := range m.memory() {
+		if start := bytes.Index(mem, modInfoStart); start >= 0 {
+			mod = mem[start+len(modInfoStart):]
+			break
+		}
+	}
+	if mod == nil {
+		return nil, fmt.Errorf("no module information in WebAssembly module %s", binaryPath)
+	}
+	end := bytes.Index(mod, modInfoEnd)
+	if end < 0 {
+		return nil, fmt.Errorf("truncated module information in WebAssembly module %s", binaryPath)
+	}
+	info, err := debug.ParseBuildInfo(string(mod[:end]))
+	if err != nil {
+		return nil, err
+	}
+	info.GoVersion = m.goVersion()
+	return info, nil
+}
+
+// wasmReader 顺序读取 WebAssembly 的 LEB128 整数和字符串，出错后的读取都返回零值
+type wasmReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *wasmReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("unexpected end of data at offset %d", r.off)
+	}
+	r.off = len(r.data)
+}
+
+func (r *wasmReader) byte() byte {
+	if r.err != nil || r.off >= len(r.data) {
+		r.fail()
+		return 0
+	}
+	r.off++
+	return r.data[r.off-1]
+}
+
+func (r *wasmReader) uleb() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.off:])
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+func (r *wasmReader) sleb() int64 {
+	var v int64
+	var shift uint
+	for {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		v |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v
+		}
+	}
+}
+
+func (r *wasmReader) bytes(n uint64) []byte {
+	if r.err != nil || n > uint64(len(r.data)-r.off) {
+		r.fail()
+		return nil
+	}
+	b := r.data[r.off : r.off+int(n)]
+	r.off += int(n)
+	return b
+}
+
+func (r *wasmReader) name() string {
+	return string(r.bytes(r.uleb()))
+}
+
+// funcImports 返回导入段中函数导入的数量
+func (r *wasmReader) funcImports() int {
+	funcs := 0
+	for n := r.uleb(); n > 0 && r.err == nil; n-- {
+		r.name() // 模块
+		r.name() // 字段
+		switch r.byte() {
+		case 0: // 函数：类型编号
+			r.uleb()
+			funcs++
+		case 1: // 表：元素类型和大小限制
+			r.byte()
+			r.limits()
+		case 2: // 内存：大小限制
+			r.limits()
+		case 3: // 全局变量：值类型和可变性
+			r.byte()
+			r.byte()
+		}
+	}
+	return funcs
+}
+
+func (r *wasmReader) limits() {
+	if r.byte()&1 != 0 {
+		r.uleb()
+	}
+	r.uleb()
+}
+
+// segments 读取数据段：活动段带有 i32.const <地址> end 形式的偏移表达式，被动段没有地址
+func (r *wasmReader) segments() []wasmSegment {
+	var segments []wasmSegment
+	for n := r.uleb(); n > 0 && r.err == nil; n-- {
+		var seg wasmSegment
+		flags := r.uleb()
+		if flags == 2 {
+			r.uleb() // 内存编号
+		}
+		if flags != 1 {
+			if op := r.byte(); op == 0x41 { // i32.const
+				seg.addr = uint64(uint32(r.sleb()))
+			}
+			for r.err == nil && r.byte() != 0x0b { // end
+			}
+		}
+		seg.data = r.bytes(r.uleb())
+		segments = append(segments, seg)
+	}
+	return segments
+}
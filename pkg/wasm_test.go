@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWasmReaderLEB128(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		signed bool
+		want   int64
+		err    bool
+	}{
+		{[]byte{0x00}, false, 0, false},
+		{[]byte{0xe5, 0x8e, 0x26}, false, 624485, false},
+		{[]byte{0x80}, false, 0, true},
+		{[]byte{0x7f}, true, -1, false},
+		{[]byte{0xc0, 0xbb, 0x78}, true, -123456, false},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x04}, true, 1 << 30, false},
+		{[]byte{0xff}, true, 0, true},
+		{nil, true, 0, true},
+	}
+	for _, tt := range tests {
+		r := &wasmReader{data: tt.data}
+		var got int64
+		if tt.signed {
+			got = r.sleb()
+		} else {
+			got = int64(r.uleb())
+		}
+		if (r.err != nil) != tt.err || got != tt.want {
+			t.Errorf("% x (signed %v) = %d, %v; want %d, error %v", tt.data, tt.signed, got, r.err, tt.want, tt.err)
+		}
+	}
+}
+
+// wasmSection 编码一个 WebAssembly 段：编号、长度和内容
+func wasmSection(id byte, body ...byte) []byte {
+	return append([]byte{id, byte(len(body))}, body...)
+}
+
+func TestParseWasm(t *testing.T) {
+	module := []byte(wasmMagic + "\x01\x00\x00\x00")
+	// 导入段：env.f（函数）和 env.mem（内存）
+	module = append(module, wasmSection(wasmSectionImport,
+		2,
+		3, 'e', 'n', 'v', 1, 'f', 0, 0,
+		3, 'e', 'n', 'v', 3, 'm', 'e', 'm', 2, 0, 1,
+	)...)
+	// 代码段：两个函数体，大小为 2 和 4
+	module = append(module, wasmSection(wasmSectionCode,
+		2,
+		2, 0, 0x0b,
+		4, 0, 0x01, 0x01, 0x0b,
+	)...)
+	// 数据段：地址 16 和 -1（按无符号 32 位解释）的活动段，以及一个被动段
+	module = append(module, wasmSection(wasmSectionData,
+		3,
+		0, 0x41, 16, 0x0b, 3, 'a', 'b', 'c',
+		0, 0x41, 0x7f, 0x0b, 0,
+		1, 2, 'x', 'y',
+	)...)
+	// name 段：函数 1 和 2 的名字
+	module = append(module, wasmSection(wasmSectionCustom,
+		4, 'n', 'a', 'm', 'e',
+		1, 16,
+		2,
+		1, 6, 'm', 'a', 'i', 'n', '.', 'f',
+		2, 5, 'o', 's', '.', 'g', 'x',
+	)...)
+
+	m, err := parseWasm(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.imports != 1 {
+		t.Errorf("imports = %d, want 1", m.imports)
+	}
+	if len(m.bodies) != 2 || m.bodies[0][1] != 2 || m.bodies[1][1] != 4 {
+		t.Errorf("bodies = %v", m.bodies)
+	}
+	if m.names[1] != "main.f" || m.names[2] != "os.gx" {
+		t.Errorf("names = %v", m.names)
+	}
+	if len(m.segments) != 3 || m.segments[0].addr != 16 || string(m.segments[0].data) != "abc" ||
+		m.segments[1].addr != 0xffffffff || string(m.segments[2].data) != "xy" {
+		t.Errorf("segments = %+v", m.segments)
+	}
+
+	for _, n := range []int{3, 9, len(module) - 1} {
+		if _, err := parseWasm(module[:n]); err == nil {
+			t.Errorf("parsing %d of %d bytes did not fail", n, len(module))
+		}
+	}
+}
+
+func TestWasmMemory(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []wasmSegment
+		want     []string
+	}{
+		{"empty", nil, nil},
+		{"adjacent", []wasmSegment{{4, []byte("cd")}, {2, []byte("ab")}}, []string{"abcd"}},
+		{"gap filled with zeros", []wasmSegment{{0, []byte("abc")}, {5, []byte("de")}}, []string{"abc\x00\x00de"}},
+		{"overlap", []wasmSegment{{0, []byte("abcd")}, {2, []byte("XY")}}, []string{"abXY"}},
+		{"gap larger than the data", []wasmSegment{{0, []byte("ab")}, {10, []byte("cd")}}, []string{"ab", "cd"}},
+		// 来自文件的地址不能让补零无限增长
+		{"far address", []wasmSegment{{0, []byte("ab")}, {0xfffffff0, []byte("cd")}}, []string{"ab", "cd"}},
+		{"high address alone", []wasmSegment{{0xfffffff0, []byte("ab")}}, []string{"ab"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, block := range (&wasmModule{segments: tt.segments}).memory() {
+			got = append(got, string(block))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: memory = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeWasm(t *testing.T) {
+	for _, env := range [][]string{{"GOOS=js", "GOARCH=wasm"}, {"GOOS=wasip1", "GOARCH=wasm"}} {
+		t.Run(env[0], func(t *testing.T) {
+			binary := buildTestBinary(t, map[string]string{"main.go": helloMain}, env)
+
+			report, err := (&GoWeight{}).AnalyzeBinary(binary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Binary.Format != "wasm" || report.Source != SourceWasm {
+				t.Errorf("format = %q, source = %q", report.Binary.Format, report.Source)
+			}
+			if report.Build.Path != "example.com/app" || report.Build.GoVersion == "" {
+				t.Errorf("build = %+v", report.Build)
+			}
+			if d := report.DataSegments; d == nil || d.Count == 0 || d.Size == 0 {
+				t.Errorf("data segments = %+v", d)
+			}
+			sizes := make(map[string]uint64)
+			for _, p := range report.Packages() {
+				sizes[p.Path] = p.Size
+			}
+			// name 段中的 internal_abi 等名字要换回真实的包路径
+			for _, path := range []string{"example.com/app", "fmt", "internal/abi", "runtime"} {
+				if sizes[path] == 0 {
+					t.Errorf("package %s has no code", path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/jondot/goweight/pkg"
+	"golang.org/x/term"
+)
+
+// tuiRow 是浏览器中的一行：模块、包或符号
+type tuiRow struct {
+	depth  int
+	key    string // 展开状态的键，符号行为空
+	label  string
+	detail string
+	size   uint64
+	module *pkg.ModuleReport
+	pkg    *pkg.PackageReport
+}
+
+// browser 是交互式终端浏览器的状态
+type browser struct {
+	report    *pkg.Report
+	loadGraph func() (*pkg.ImportGraph, error)
+	graph     *pkg.ImportGraph
+
+	expanded   map[string]bool
+	sortByName bool
+	terms      []string // 搜索关键字（全部匹配）
+	minSize    uint64   // 搜索中的 >SIZE 过滤条件
+	hideStd    bool
+	hideThird  bool
+	hideMain   bool
+
+	rows   []tuiRow
+	cursor int
+	offset int
+
+	searching bool
+	input     string
+	overlay   []string // why 导入链等覆盖层内容
+	status    string
+}
+
+// runTUI 在终端中交互式浏览分析报告
+func runTUI(report *pkg.Report, loadGraph func() (*pkg.ImportGraph, error)) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("tui needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	// 使用备用屏幕并隐藏光标，退出时恢复
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, state)
+	}()
+
+	b := &browser{report: report, loadGraph: loadGraph, expanded: make(map[string]bool)}
+	b.rebuild()
+
+	buf := make([]byte, 32)
+	for {
+		b.render()
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		if !b.handleKey(parseKey(buf[:n])) {
+			return nil
+		}
+	}
+}
+
+// parseKey 把一次读到的输入转换为按键名
+func parseKey(in []byte) string {
+	switch string(in) {
+	case "\x1b[A", "\x1bOA":
+		return "up"
+	case "\x1b[B", "\x1bOB":
+		return "down"
+	case "\x1b[C", "\x1bOC":
+		return "right"
+	case "\x1b[D", "\x1bOD":
+		return "left"
+	case "\x1b[5~":
+		return "pgup"
+	case "\x1b[6~":
+		return "pgdn"
+	case "\x1b[H", "\x1b[1~":
+		return "home"
+	case "\x1b[F", "\x1b[4~":
+		return "end"
+	case "\x1b":
+		return "esc"
+	case "\r", "\n":
+		return "enter"
+	case "\x7f", "\x08":
+		return "backspace"
+	case "\x03":
+		return "ctrl-c"
+	}
+	return string(in)
+}
+
+// handleKey 处理一次按键，返回 false 表示退出
+func (b *browser) handleKey(key string) bool {
+	if key == "ctrl-c" {
+		return false
+	}
+	if b.overlay != nil {
+		b.overlay = nil
+		return true
+	}
+	if b.searching {
+		switch key {
+		case "enter":
+			b.searching = false
+			b.setFilter(b.input)
+		case "esc":
+			b.searching = false
+		case "backspace":
+			if len(b.input) > 0 {
+				b.input = b.input[:len(b.input)-1]
+			}
+		case "up", "down", "left", "right", "pgup", "pgdn", "home", "end":
+		default:
+			// 粘贴时一次会读到多个字符
+			for _, r := range key {
+				switch {
+				case r == '\r' || r == '\n':
+					b.searching = false
+					b.setFilter(b.input)
+				case r >= ' ' && r != 0x7f && b.searching:
+					b.input += string(r)
+				}
+			}
+		}
+		return true
+	}
+
+	b.status = ""
+	_, pageHeight := b.size()
+	switch key {
+	case "q":
+		return false
+	case "up", "k":
+		b.cursor--
+	case "down", "j":
+		b.cursor++
+	case "pgup":
+		b.cursor -= pageHeight
+	case "pgdn":
+		b.cursor += pageHeight
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = len(b.rows) - 1
+	case "enter", " ", "right", "l":
+		if row := b.current(); row != nil && row.key != "" {
+			b.expanded[row.key] = key == "right" || key == "l" || !b.expanded[row.key]
+			b.rebuild()
+		}
+	case "left", "h":
+		b.collapse()
+	case "s":
+		b.sortByName = !b.sortByName
+		b.rebuild()
+	case "/":
+		b.searching = true
+		b.input = strings.Join(b.terms, " ")
+		if b.minSize > 0 {
+			b.input = strings.TrimSpace(b.input + " >" + humanize.Bytes(b.minSize))
+		}
+	case "esc":
+		b.setFilter("")
+	case "1":
+		b.hideStd = !b.hideStd
+		b.rebuild()
+	case "2":
+		b.hideThird = !b.hideThird
+		b.rebuild()
+	case "3":
+		b.hideMain = !b.hideMain
+		b.rebuild()
+	case "w":
+		b.showWhy()
+	}
+	b.clampCursor()
+	return true
+}
+
+// setFilter 解析搜索输入：普通关键字按子串匹配，>SIZE 过滤掉较小的条目
+func (b *browser) setFilter(input string) {
+	b.terms = nil
+	b.minSize = 0
+	for _, field := range strings.Fields(input) {
+		if strings.HasPrefix(field, ">") {
+			size, err := humanize.ParseBytes(field[1:])
+			if err != nil {
+				b.status = fmt.Sprintf("invalid size filter %q", field)
+				continue
+			}
+			b.minSize = size
+			continue
+		}
+		b.terms = append(b.terms, strings.ToLower(field))
+	}
+	b.cursor = 0
+	b.rebuild()
+}
+
+// matches 判断文本是否包含所有搜索关键字
+func (b *browser) matches(text string) bool {
+	text = strings.ToLower(text)
+	for _, t := range b.terms {
+		if !strings.Contains(text, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// moduleHidden 根据 std / 第三方 / 主模块开关判断是否隐藏模块
+func (b *browser) moduleHidden(module *pkg.ModuleReport) bool {
+	switch {
+	case module.Path == pkg.StdModule:
+		return b.hideStd
+	case module.Main:
+		return b.hideMain
+	default:
+		return b.hideThird
+	}
+}
+
+// rebuild 根据展开状态、排序和过滤条件重新生成可见行
+func (b *browser) rebuild() {
+	var selected string
+	if row := b.current(); row != nil {
+		selected = row.key + "\x00" + row.label
+	}
+
+	filtering := len(b.terms) > 0
+	b.rows = b.rows[:0]
+	for _, module := range b.sortedModules() {
+		if b.moduleHidden(module) || module.Size < b.minSize {
+			continue
+		}
+		moduleMatch := b.matches(module.Path)
+		var pkgRows []tuiRow
+		for _, p := range b.sortedPackages(module.Packages) {
+			if p.Size < b.minSize {
+				continue
+			}
+			pkgMatch := moduleMatch || b.matches(p.Path)
+			var symRows []tuiRow
+			for _, sym := range b.sortedSymbols(p.Symbols) {
+				if sym.Size < b.minSize || !(pkgMatch || b.matches(sym.Name)) {
+					continue
+				}
+				symRows = append(symRows, tuiRow{depth: 2, label: sym.Name, detail: sym.Section, size: sym.Size, module: module, pkg: p})
+			}
+			if !pkgMatch && len(symRows) == 0 {
+				continue
+			}
+			key := "p:" + p.Path
+			pkgRows = append(pkgRows, tuiRow{depth: 1, key: key, label: p.Path, size: p.Size, module: module, pkg: p})
+			// 只有符号命中搜索时自动展开
+			if b.expanded[key] || (filtering && !pkgMatch) {
+				pkgRows = append(pkgRows, symRows...)
+			}
+		}
+		if !moduleMatch && len(pkgRows) == 0 {
+			continue
+		}
+		key := "m:" + module.Path
+		b.rows = append(b.rows, tuiRow{depth: 0, key: key, label: module.Path, detail: module.Version, size: module.Size, module: module})
+		if b.expanded[key] || (filtering && !moduleMatch) {
+			b.rows = append(b.rows, pkgRows...)
+		}
+	}
+
+	// 尽量保持光标停留在同一行
+	for i, row := range b.rows {
+		if row.key+"\x00"+row.label == selected {
+			b.cursor = i
+			break
+		}
+	}
+	b.clampCursor()
+}
+
+func (b *browser) sortedModules() []*pkg.ModuleReport {
+	modules := append([]*pkg.ModuleReport(nil), b.report.Modules...)
+	if b.sortByName {
+		sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	}
+	return modules
+}
+
+func (b *browser) sortedPackages(packages []*pkg.PackageReport) []*pkg.PackageReport {
+	packages = append([]*pkg.PackageReport(nil), packages...)
+	if b.sortByName {
+		sort.Slice(packages, func(i, j int) bool { return packages[i].Path < packages[j].Path })
+	}
+	return packages
+}
+
+func (b *browser) sortedSymbols(symbols []*pkg.Symbol) []*pkg.Symbol {
+	symbols = append([]*pkg.Symbol(nil), symbols...)
+	if b.sortByName {
+		sort.Slice(symbols, func(i, j int) bool { return symbols[i].Name < symbols[j].Name })
+	}
+	return symbols
+}
+
+// current 返回光标所在的行
+func (b *browser) current() *tuiRow {
+	if b.cursor < 0 || b.cursor >= len(b.rows) {
+		return nil
+	}
+	return &b.rows[b.cursor]
+}
+
+// collapse 折叠当前行；已折叠或是符号行时跳到父节点
+func (b *browser) collapse() {
+	row := b.current()
+	if row == nil {
+		return
+	}
+	if row.key != "" && b.expanded[row.key] {
+		b.expanded[row.key] = false
+		b.rebuild()
+		return
+	}
+	for i := b.cursor - 1; i >= 0; i-- {
+		if b.rows[i].depth < row.depth {
+			b.cursor = i
+			return
+		}
+	}
+}
+
+// showWhy 显示从主包到当前包的导入链
+func (b *browser) showWhy() {
+	row := b.current()
+	if row == nil {
+		return
+	}
+	if b.graph == nil {
+		graph, err := b.loadGraph()
+		if err != nil {
+			b.overlay = []string{"Could not load the import graph:", "", err.Error()}
+			return
+		}
+		b.graph = graph
+	}
+
+	target := row.label
+	if row.pkg != nil {
+		target = row.pkg.Path
+	} else if len(row.module.Packages) > 0 {
+		// 模块行取其中最大的包
+		target = row.module.Packages[0].Path
+	}
+	chain := b.graph.ImportChain(target)
+	if chain == nil {
+		b.overlay = []string{fmt.Sprintf("%s is not reachable in the import graph", target)}
+		return
+	}
+	b.overlay = []string{"why " + target, ""}
+	for i, p := range chain {
+		size := uint64(0)
+		if node, ok := b.graph.Nodes[p]; ok {
+			size = node.Size
+		}
+		b.overlay = append(b.overlay, fmt.Sprintf("%s%s (%s)", strings.Repeat("  ", i), p, humanize.Bytes(size)))
+	}
+}
+
+// size 返回终端宽度和列表可用的高度
+func (b *browser) size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	// 顶部标题一行，底部状态两行
+	return width, height - 3
+}
+
+func (b *browser) clampCursor() {
+	if b.cursor >= len(b.rows) {
+		b.cursor = len(b.rows) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// render 重新绘制整个屏幕
+func (b *browser) render() {
+	width, height := b.size()
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+
+	title := fmt.Sprintf("goweight  %s  %s  (%s, %s)", b.report.Binary.Path, humanize.Bytes(b.report.Binary.Size), b.report.Source, b.report.Build.GoVersion)
+	sb.WriteString("\x1b[1m" + truncate(title, width) + "\x1b[0m\r\n")
+
+	if b.overlay != nil {
+		for i, line := range b.overlay {
+			if i >= height {
+				break
+			}
+			sb.WriteString(truncate(line, width) + "\r\n")
+		}
+		for i := len(b.overlay); i < height; i++ {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString("\r\n\x1b[7m" + truncate(" press any key to return", width) + "\x1b[0m")
+		fmt.Print(sb.String())
+		return
+	}
+
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+height {
+		b.offset = b.cursor - height + 1
+	}
+	for i := b.offset; i < b.offset+height; i++ {
+		if i >= len(b.rows) {
+			sb.WriteString("\r\n")
+			continue
+		}
+		row := b.rows[i]
+		marker := "  "
+		if row.key != "" {
+			marker = "▸ "
+			if b.expanded[row.key] {
+				marker = "▾ "
+			}
+		}
+		percent := 0.0
+		if b.report.Binary.Size > 0 {
+			percent = float64(row.size) * 100 / float64(b.report.Binary.Size)
+		}
+		line := fmt.Sprintf("%9s %5.1f%% %s%s%s", humanize.Bytes(row.size), percent, strings.Repeat("  ", row.depth), marker, row.label)
+		if row.detail != "" {
+			line += "  " + row.detail
+		}
+		line = truncate(line, width)
+		if i == b.cursor {
+			line = "\x1b[7m" + line + strings.Repeat(" ", max(0, width-len([]rune(line)))) + "\x1b[0m"
+		}
+		sb.WriteString(line + "\r\n")
+	}
+
+	var flags []string
+	for _, t := range []struct {
+		name   string
+		hidden bool
+	}{{"1:std", b.hideStd}, {"2:third-party", b.hideThird}, {"3:main", b.hideMain}} {
+		if t.hidden {
+			flags = append(flags, t.name+" off")
+		} else {
+			flags = append(flags, t.name+" on")
+		}
+	}
+	sortName := "size"
+	if b.sortByName {
+		sortName = "name"
+	}
+	info := fmt.Sprintf(" %d/%d  sort:%s  %s", b.cursor+1, len(b.rows), sortName, strings.Join(flags, "  "))
+	if len(b.terms) > 0 || b.minSize > 0 {
+		info += "  filter:" + strings.Join(b.terms, " ")
+		if b.minSize > 0 {
+			info += " >" + humanize.Bytes(b.minSize)
+		}
+	}
+	if b.status != "" {
+		info += "  " + b.status
+	}
+	sb.WriteString("\x1b[7m" + truncate(info, width) + strings.Repeat(" ", max(0, width-len([]rune(info)))) + "\x1b[0m\r\n")
+
+	if b.searching {
+		sb.WriteString(truncate("/"+b.input, width))
+		sb.WriteString("\x1b[?25h")
+	} else {
+		sb.WriteString(truncate("↑↓ move  ⏎/→ expand  ← collapse  s sort  / search  esc clear  1-3 toggle  w why  q quit", width))
+		sb.WriteString("\x1b[?25l")
+	}
+	fmt.Print(sb.String())
+}
+
+// truncate 把字符串截断到终端宽度
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jondot/goweight/pkg"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"\x1b[A", "up"},
+		{"\x1bOB", "down"},
+		{"\x1b[5~", "pgup"},
+		{"\x1b[4~", "end"},
+		{"\r", "enter"},
+		{"\x7f", "backspace"},
+		{"\x03", "ctrl-c"},
+		{"q", "q"},
+		{"net/http", "net/http"},
+	}
+	for _, tt := range tests {
+		if got := parseKey([]byte(tt.in)); got != tt.want {
+			t.Errorf("parseKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func testBrowser() *browser {
+	report := &pkg.Report{Modules: []*pkg.ModuleReport{
+		{Path: pkg.StdModule, Size: 5000, Packages: []*pkg.PackageReport{
+			{Path: "runtime", Size: 4000, Symbols: []*pkg.Symbol{{Name: "runtime.mallocgc", Size: 4000}}},
+			{Path: "fmt", Size: 1000, Symbols: []*pkg.Symbol{{Name: "fmt.Println", Size: 1000}}},
+		}},
+		{Path: "example.com/app", Main: true, Size: 300, Packages: []*pkg.PackageReport{
+			{Path: "example.com/app", Size: 300, Symbols: []*pkg.Symbol{
+				{Name: "main.main", Size: 200},
+				{Name: "main.helper", Size: 100},
+			}},
+		}},
+	}}
+	b := &browser{report: report, expanded: make(map[string]bool)}
+	b.rebuild()
+	return b
+}
+
+func rowLabels(b *browser) []string {
+	var labels []string
+	for _, row := range b.rows {
+		labels = append(labels, row.label)
+	}
+	return labels
+}
+
+func TestBrowserFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		keys   []string
+		want   []string
+	}{
+		{"collapsed", "", nil, []string{"std", "example.com/app"}},
+		{"expand module", "", []string{"enter"}, []string{"std", "runtime", "fmt", "example.com/app"}},
+		{"symbol match expands its parents", "helper", nil, []string{"example.com/app", "example.com/app", "main.helper"}},
+		{"size filter", ">2KB", nil, []string{"std"}},
+		{"hide std", "", []string{"1"}, []string{"example.com/app"}},
+		{"sort by name", "", []string{"s"}, []string{"example.com/app", "std"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testBrowser()
+			if tt.filter != "" {
+				b.setFilter(tt.filter)
+			}
+			for _, key := range tt.keys {
+				b.handleKey(key)
+			}
+			if got := rowLabels(b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrowserInvalidSizeFilter(t *testing.T) {
+	b := testBrowser()
+	b.setFilter(">lots")
+	if b.status == "" || b.minSize != 0 {
+		t.Errorf("invalid size filter: status %q, minSize %d", b.status, b.minSize)
+	}
+}